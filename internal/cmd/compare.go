@@ -0,0 +1,110 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/terraform-equivalence-testing/internal/terraform"
+	"github.com/hashicorp/terraform-equivalence-testing/internal/tests"
+)
+
+func CompareCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &compareCommand{
+			ui: ui,
+		}, nil
+	}
+}
+
+type compareCommand struct {
+	ui cli.Ui
+}
+
+func (cmd *compareCommand) Help() string {
+	return strings.TrimSpace(`
+Usage: terraform-equivalence-testing compare --binary-a=terraform-1.4 --binary-b=terraform-1.5 --tests=examples/example_test_cases [--filters=complex_resource,simple_resource] [--parallelism=1] [--timeout=10m] [--diff-format=unified] [--json-diff-format=paths] [--diff-context=3] [--color]
+
+Compare and report the diff between the outputs of two different Terraform binaries.
+
+This command will execute all the test cases within the tests directory twice, once with each binary, and report any differences between the two sets of outputs. No golden files are read or written.
+`)
+}
+
+func (cmd *compareCommand) Run(args []string) int {
+	flags, err := ParseFlags("compare", args)
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return 1
+	}
+
+	tfA, err := terraform.New(flags.TerraformBinaryPathA)
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return 1
+	}
+
+	tfB, err := terraform.New(flags.TerraformBinaryPathB)
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return 1
+	}
+	cmd.ui.Output(fmt.Sprintf("Comparing equivalence tests using Terraform v%s with command `%s` against Terraform v%s with command `%s`", tfA.Version(), flags.TerraformBinaryPathA, tfB.Version(), flags.TerraformBinaryPathB))
+
+	testCases, err := tests.ReadFrom(flags.TestingFilesDirectory, flags.TestFilters...)
+	if err != nil {
+		cmd.ui.Error(err.Error())
+		return 1
+	}
+	cmd.ui.Output(fmt.Sprintf("Found %d test cases in %s\n", len(testCases), flags.TestingFilesDirectory))
+
+	results := runTests(cmd.ui, testCases, flags.Parallelism, func(test tests.Test, ui cli.Ui) testResult {
+		return cmd.runTest(test, tfA, tfB, flags, ui)
+	})
+
+	return reportDiffSummary(results, cmd.ui)
+}
+
+func (cmd *compareCommand) runTest(test tests.Test, tfA, tfB terraform.Terraform, flags *Flags, ui cli.Ui) testResult {
+	ui.Output(fmt.Sprintf("[%s]: starting with --binary-a...", test.Name))
+
+	outputA, err := runWithTimeout(test, tfA, flags)
+	if err != nil {
+		return reportRunError(test, err, ui)
+	}
+
+	ui.Output(fmt.Sprintf("[%s]: starting with --binary-b...", test.Name))
+
+	outputB, err := runWithTimeout(test, tfB, flags)
+	if err != nil {
+		return reportRunError(test, err, ui)
+	}
+
+	ui.Output(fmt.Sprintf("[%s]: computing diffs...", test.Name))
+
+	files, err := outputA.CompareTo(outputB, flags.diffOptions())
+	if err != nil {
+		ui.Output(fmt.Sprintf("[%s]: unknown error (%v)", test.Name, err))
+		return testResult{Status: testFailed, Err: err}
+	}
+
+	status := reportDiffs(test, files, binaryDiffMessages, flags, ui)
+	return testResult{Status: status, Files: files}
+}
+
+// runWithTimeout executes test with tf. Each call gets its own context, so both
+// binaries get the full timeout of the test case.
+func runWithTimeout(test tests.Test, tf terraform.Terraform, flags *Flags) (tests.TestOutput, error) {
+	ctx, cancel := testContext(test, flags)
+	defer cancel()
+
+	return test.RunWith(ctx, tf)
+}
+
+func (cmd *compareCommand) Synopsis() string {
+	return "Compare and report the diff between the outputs of two different Terraform binaries."
+}
@@ -27,7 +27,7 @@ type diffCommand struct {
 
 func (cmd *diffCommand) Help() string {
 	return strings.TrimSpace(`
-Usage: terraform-equivalence-testing diff --goldens=examples/example_golden_files --tests=examples/example_test_cases [--binary=terraform] [--filters=complex_resource,simple_resource]
+Usage: terraform-equivalence-testing diff --goldens=examples/example_golden_files --tests=examples/example_test_cases [--binary=terraform] [--filters=complex_resource,simple_resource] [--parallelism=1] [--timeout=10m] [--report=report.json] [--junit=report.xml] [--strict-ignores] [--diff-format=unified] [--json-diff-format=paths] [--diff-context=3] [--color]
 
 Compare and report the diff between a fresh run of the equivalence tests and the golden files.
 
@@ -56,79 +56,56 @@ func (cmd *diffCommand) Run(args []string) int {
 	}
 	cmd.ui.Output(fmt.Sprintf("Found %d test cases in %s\n", len(testCases), flags.TestingFilesDirectory))
 
-	successfulTests := 0
-	testsWithDiffs := 0
-	failedTests := 0
-
-	for _, test := range testCases {
-		cmd.ui.Output(fmt.Sprintf("[%s]: starting...", test.Name))
-
-		output, err := test.RunWith(tf)
-		if err != nil {
-			failedTests++
-			if tfErr, ok := err.(terraform.Error); ok {
-				cmd.ui.Output(fmt.Sprintf("[%s]: %s", test.Name, tfErr))
-				continue
-			}
-			cmd.ui.Output(fmt.Sprintf("[%s]: unknown error (%v)", test.Name, err))
-			continue
-		}
-
-		cmd.ui.Output(fmt.Sprintf("[%s]: computing diffs...", test.Name))
+	results := runTests(cmd.ui, testCases, flags.Parallelism, func(test tests.Test, ui cli.Ui) testResult {
+		return cmd.runTest(test, tf, flags, ui)
+	})
 
-		files, err := output.ComputeDiff(flags.GoldenFilesDirectory)
-		if err != nil {
-			failedTests++
-			cmd.ui.Output(fmt.Sprintf("[%s]: unknown error (%v)", test.Name, err))
-			continue
-		}
+	exitCode := reportDiffSummary(results, cmd.ui)
 
-		newFileCount := 0
-		noChangeCount := 0
-		changeCount := 0
-
-		for file, diff := range files {
-			switch diff {
-			case tests.NewFile:
-				newFileCount++
-				cmd.ui.Output(fmt.Sprintf("[%s]: %s was a new file", test.Name, file))
-			case tests.NoChange:
-				noChangeCount++
-				cmd.ui.Output(fmt.Sprintf("[%s]: %s had no diffs", test.Name, file))
-			default:
-				changeCount++
-				cmd.ui.Output(fmt.Sprintf("[%s]: %s had diffs (-want +got):\n%s", test.Name, file, diff))
-			}
+	if len(flags.Report) > 0 {
+		if err := writeReport(flags.Report, newReport(tf, flags.TerraformBinaryPath, flags.JsonDiffFormat == tests.PatchDiff, results)); err != nil {
+			cmd.ui.Error(fmt.Sprintf("failed to write report: %v", err))
+			return 1
 		}
+	}
 
-		successfulTests++
-		if newFileCount+changeCount > 0 {
-			testsWithDiffs++
+	if len(flags.JUnit) > 0 {
+		if err := writeJUnitReport(flags.JUnit, newJUnitReport(tf, results)); err != nil {
+			cmd.ui.Error(fmt.Sprintf("failed to write JUnit report: %v", err))
+			return 1
 		}
-
-		cmd.ui.Output(fmt.Sprintf("[%s]: complete\n", test.Name))
 	}
 
-	cmd.ui.Output(fmt.Sprintf("Equivalence testing complete."))
-	cmd.ui.Output(fmt.Sprintf("\tAttempted %d test(s).", len(testCases)))
+	return exitCode
+}
 
-	exitCode := 0
+func (cmd *diffCommand) runTest(test tests.Test, tf terraform.Terraform, flags *Flags, ui cli.Ui) testResult {
+	ui.Output(fmt.Sprintf("[%s]: starting...", test.Name))
 
-	if successfulTests > 0 {
-		cmd.ui.Output(fmt.Sprintf("\t%d test(s) were successful.", successfulTests))
+	ctx, cancel := testContext(test, flags)
+	defer cancel()
+
+	output, err := test.RunWith(ctx, tf)
+	if err != nil {
+		return reportRunError(test, err, ui)
 	}
 
-	if testsWithDiffs > 0 {
-		exitCode = 2 // non-zero exit code to indicate diffs, but different from failed tests
-		cmd.ui.Output(fmt.Sprintf("\t%d test(s) had diffs.", testsWithDiffs))
+	warnings, err := reportWarnings(test, output, flags, ui)
+	if err != nil {
+		ui.Output(fmt.Sprintf("[%s]: %v", test.Name, err))
+		return testResult{Status: testFailed, Warnings: warnings, Err: err}
 	}
 
-	if failedTests > 0 {
-		exitCode = 1 // failed tests should have a non-zero exit code
-		cmd.ui.Output(fmt.Sprintf("\t%d test(s) failed.", failedTests))
+	ui.Output(fmt.Sprintf("[%s]: computing diffs...", test.Name))
+
+	files, err := output.ComputeDiff(flags.GoldenFilesDirectory, flags.diffOptions())
+	if err != nil {
+		ui.Output(fmt.Sprintf("[%s]: unknown error (%v)", test.Name, err))
+		return testResult{Status: testFailed, Warnings: warnings, Err: err}
 	}
 
-	return exitCode
+	status := reportDiffs(test, files, goldenDiffMessages, flags, ui)
+	return testResult{Status: status, Files: files, Warnings: warnings}
 }
 
 func (cmd *diffCommand) Synopsis() string {
@@ -6,7 +6,12 @@ package cmd
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/terraform-equivalence-testing/internal/diff"
+	"github.com/hashicorp/terraform-equivalence-testing/internal/tests"
 )
 
 // Flags is a helpful struct that contains the global flags for the equivalence
@@ -23,9 +28,51 @@ type Flags struct {
 	// The relative or absolute path to the target Terraform binary.
 	TerraformBinaryPath string
 
+	// The relative or absolute paths to the two Terraform binaries that are
+	// compared against each other by the compare command. Binary A produces
+	// the expected outputs, and binary B the actual outputs.
+	TerraformBinaryPathA string
+	TerraformBinaryPathB string
+
 	// If empty, then all tests will be executed. If not empty, only tests
 	// included in this flag will be executed.
 	TestFilters StringList
+
+	// The maximum number of test cases that should be executed concurrently.
+	Parallelism int
+
+	// The maximum amount of time a single test case is allowed to run for,
+	// unless the test specification overrides it. Zero means no timeout.
+	Timeout time.Duration
+
+	// If not empty, the diff command writes a machine-readable JSON report
+	// of the results into this file.
+	Report string
+
+	// If not empty, the diff and update commands write a JUnit XML report of
+	// the results into this file.
+	JUnit string
+
+	// The format of the diffs for raw text files printed by the diff and
+	// compare commands, either tests.UnifiedDiff or tests.CmpDiff.
+	DiffFormat string
+
+	// The format of the diffs for JSON files printed by the diff and compare
+	// commands, either tests.PathsDiff, tests.PatchDiff or tests.CmpDiff.
+	JsonDiffFormat string
+
+	// The number of unchanged lines included around each change in unified
+	// diffs.
+	DiffContext int
+
+	// If true, the diff and compare commands print diffs with ANSI colors.
+	// Reports are always written without colors.
+	Color bool
+
+	// If true, the diff and update commands fail any test case with warnings
+	// about its specification, such as ignore_fields entries that didn't
+	// match anything, instead of just reporting them.
+	StrictIgnores bool
 }
 
 func ParseFlags(command string, args []string) (*Flags, error) {
@@ -33,17 +80,49 @@ func ParseFlags(command string, args []string) (*Flags, error) {
 
 	flags := Flags{}
 
-	fs.StringVar(&flags.GoldenFilesDirectory, "goldens", "", "Absolute or relative path to the directory containing the golden files.")
+	// The compare command runs the tests with two binaries instead of
+	// comparing a single binary against the golden files.
+	compare := command == "compare"
+
+	if compare {
+		fs.StringVar(&flags.TerraformBinaryPathA, "binary-a", "", "Absolute or relative path to the Terraform binary that produces the expected outputs.")
+		fs.StringVar(&flags.TerraformBinaryPathB, "binary-b", "", "Absolute or relative path to the Terraform binary that produces the actual outputs.")
+	} else {
+		fs.StringVar(&flags.GoldenFilesDirectory, "goldens", "", "Absolute or relative path to the directory containing the golden files.")
+		fs.StringVar(&flags.TerraformBinaryPath, "binary", "terraform", "Absolute or relative path to the target Terraform binary.")
+	}
+	if command == "diff" {
+		fs.StringVar(&flags.Report, "report", "", "If specified, a JSON report of the results will be written to this file.")
+	}
+	if command == "diff" || command == "compare" {
+		fs.StringVar(&flags.DiffFormat, "diff-format", tests.UnifiedDiff, "The format of the diffs for raw text files, either unified or cmp.")
+		fs.StringVar(&flags.JsonDiffFormat, "json-diff-format", tests.PathsDiff, "The format of the diffs for JSON files, either paths, patch or cmp.")
+		fs.IntVar(&flags.DiffContext, "diff-context", diff.DefaultContext, "The number of unchanged lines to include around each change in unified diffs.")
+		fs.BoolVar(&flags.Color, "color", false, "If specified, diffs are printed with colors.")
+	}
+	if command == "diff" || command == "update" {
+		fs.StringVar(&flags.JUnit, "junit", "", "If specified, a JUnit XML report of the results will be written to this file.")
+		fs.BoolVar(&flags.StrictIgnores, "strict-ignores", false, "If specified, test cases fail if any of their ignore_fields or ignore_lines entries don't match anything.")
+	}
 	fs.StringVar(&flags.TestingFilesDirectory, "tests", "", "Absolute or relative path to the directory containing the tests and specifications.")
-	fs.StringVar(&flags.TerraformBinaryPath, "binary", "terraform", "Absolute or relative path to the target Terraform binary.")
 
 	fs.Var(&flags.TestFilters, "filters", "If specified, only test cases included in this list will be executed.")
+	fs.IntVar(&flags.Parallelism, "parallelism", 1, "The maximum number of test cases to execute concurrently.")
+	fs.DurationVar(&flags.Timeout, "timeout", 0, "The maximum amount of time a single test case can run for, eg. 10m. Defaults to no timeout.")
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
 	}
 
-	if len(flags.GoldenFilesDirectory) == 0 {
+	if compare {
+		if len(flags.TerraformBinaryPathA) == 0 {
+			return nil, errors.New("--binary-a flag is required")
+		}
+
+		if len(flags.TerraformBinaryPathB) == 0 {
+			return nil, errors.New("--binary-b flag is required")
+		}
+	} else if len(flags.GoldenFilesDirectory) == 0 {
 		return nil, errors.New("--goldens flag is required")
 	}
 
@@ -51,20 +130,42 @@ func ParseFlags(command string, args []string) (*Flags, error) {
 		return nil, errors.New("--tests flag is required")
 	}
 
-	// Last thing, let's change the TerraformBinaryPath into an absolute path as
-	// we are messing around with the working directory later. One exception is
-	// if the caller has asked to just execute the default Terraform system
-	// command/binary.
-	if !filepath.IsAbs(flags.TerraformBinaryPath) && flags.TerraformBinaryPath != "terraform" {
-		path, err := filepath.Abs(flags.TerraformBinaryPath)
-		if err != nil {
-			return nil, err
-		}
-		flags.TerraformBinaryPath = path
+	if flags.Parallelism < 1 {
+		return nil, errors.New("--parallelism flag must be at least 1")
+	}
+
+	if flags.Timeout < 0 {
+		return nil, errors.New("--timeout flag must not be negative")
+	}
+
+	if flags.DiffFormat != "" && flags.DiffFormat != tests.UnifiedDiff && flags.DiffFormat != tests.CmpDiff {
+		return nil, fmt.Errorf("--diff-format flag must be either %s or %s", tests.UnifiedDiff, tests.CmpDiff)
+	}
+
+	if flags.JsonDiffFormat != "" && flags.JsonDiffFormat != tests.PathsDiff && flags.JsonDiffFormat != tests.PatchDiff && flags.JsonDiffFormat != tests.CmpDiff {
+		return nil, fmt.Errorf("--json-diff-format flag must be one of %s, %s or %s", tests.PathsDiff, tests.PatchDiff, tests.CmpDiff)
+	}
+
+	if flags.DiffContext < 0 {
+		return nil, errors.New("--diff-context flag must not be negative")
+	}
+
+	// Last thing, let's change the Terraform binary paths into absolute paths
+	// as the Terraform commands are executed from within the test directories
+	// later.
+	var err error
+	if flags.TerraformBinaryPath, err = binaryPath(flags.TerraformBinaryPath); err != nil {
+		return nil, err
+	}
+	if flags.TerraformBinaryPathA, err = binaryPath(flags.TerraformBinaryPathA); err != nil {
+		return nil, err
+	}
+	if flags.TerraformBinaryPathB, err = binaryPath(flags.TerraformBinaryPathB); err != nil {
+		return nil, err
 	}
 
 	// Make directory paths absolute, too
-	if !filepath.IsAbs(flags.GoldenFilesDirectory) {
+	if len(flags.GoldenFilesDirectory) > 0 && !filepath.IsAbs(flags.GoldenFilesDirectory) {
 		path, err := filepath.Abs(flags.GoldenFilesDirectory)
 		if err != nil {
 			return nil, err
@@ -82,3 +183,31 @@ func ParseFlags(command string, args []string) (*Flags, error) {
 
 	return &flags, nil
 }
+
+// diffOptions returns the options for rendering diffs set by the flags.
+func (flags *Flags) diffOptions() tests.DiffOptions {
+	return tests.DiffOptions{
+		Format:     flags.DiffFormat,
+		JsonFormat: flags.JsonDiffFormat,
+		Context:    flags.DiffContext,
+	}
+}
+
+// printableDiff returns diff ready to be printed, with colors added if the
+// --color flag was set.
+func (flags *Flags) printableDiff(text string) string {
+	if flags.Color {
+		return diff.Colorize(text)
+	}
+	return text
+}
+
+// binaryPath turns a relative path to a Terraform binary into an absolute
+// path. Empty paths, and the default Terraform system command/binary, are
+// returned unchanged.
+func binaryPath(binary string) (string, error) {
+	if len(binary) == 0 || binary == "terraform" || filepath.IsAbs(binary) {
+		return binary, nil
+	}
+	return filepath.Abs(binary)
+}
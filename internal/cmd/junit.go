@@ -0,0 +1,148 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package cmd
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/terraform-equivalence-testing/internal/terraform"
+)
+
+// junitTestSuites is the root element of a JUnit XML report, written to the
+// path given by the --junit flag.
+//
+// Each test case becomes a testsuite, and each file produced by the test case
+// becomes a testcase within it. For the update command, every golden file that
+// was written becomes a passing testcase. Test cases that failed before
+// producing any files contain a single testcase named after the test case
+// itself.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr,omitempty"`
+	Contents string `xml:",chardata"`
+}
+
+func newJUnitReport(tf terraform.Terraform, results []testResult) junitTestSuites {
+	report := junitTestSuites{
+		Name: fmt.Sprintf("terraform-equivalence-testing (Terraform v%s)", tf.Version()),
+	}
+
+	for _, result := range results {
+		suite := junitTestSuite{
+			Name: result.Test.Name,
+			Time: fmt.Sprintf("%.3f", result.Duration.Seconds()),
+		}
+
+		switch {
+		case result.Err != nil:
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      result.Test.Name,
+				ClassName: result.Test.Name,
+				Error:     newJUnitError(result),
+			})
+		case len(result.Updated) > 0:
+			for _, name := range result.Updated {
+				suite.TestCases = append(suite.TestCases, junitTestCase{
+					Name:      name,
+					ClassName: result.Test.Name,
+				})
+			}
+		case len(result.Files) == 0:
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      result.Test.Name,
+				ClassName: result.Test.Name,
+			})
+		default:
+			for _, file := range newFileReports(result.Files, false) {
+				testCase := junitTestCase{
+					Name:      file.Name,
+					ClassName: result.Test.Name,
+				}
+
+				switch file.State {
+				case fileNew:
+					testCase.Failure = &junitProblem{Message: fmt.Sprintf("%s was a new file", file.Name)}
+				case fileRemoved:
+					testCase.Failure = &junitProblem{Message: fmt.Sprintf("%s was removed", file.Name)}
+				case fileChanged:
+					testCase.Failure = &junitProblem{
+						Message:  fmt.Sprintf("%s had diffs (-want +got)", file.Name),
+						Contents: file.Diff,
+					}
+				}
+
+				suite.TestCases = append(suite.TestCases, testCase)
+			}
+		}
+
+		for _, testCase := range suite.TestCases {
+			suite.Tests++
+			if testCase.Failure != nil {
+				suite.Failures++
+			}
+			if testCase.Error != nil {
+				suite.Errors++
+			}
+		}
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Suites = append(report.Suites, suite)
+	}
+	return report
+}
+
+func newJUnitError(result testResult) *junitProblem {
+	problem := junitProblem{
+		Message: result.Err.Error(),
+		Type:    result.Status.String(),
+	}
+
+	// Include the stderr output of the failing Terraform command as the body
+	// of the error, as that is where Terraform writes its diagnostics.
+	var tfErr terraform.Error
+	if errors.As(result.Err, &tfErr) && tfErr.Terraform != nil {
+		problem.Contents = tfErr.Terraform.Error()
+	}
+
+	return &problem
+}
+
+// writeJUnitReport writes report as JUnit XML into the file at target.
+func writeJUnitReport(target string, report junitTestSuites) error {
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(target, append([]byte(xml.Header), data...), 0644)
+}
@@ -0,0 +1,101 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package cmd
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hashicorp/terraform-equivalence-testing/internal/terraform"
+	"github.com/hashicorp/terraform-equivalence-testing/internal/tests"
+)
+
+func TestNewJUnitReport(t *testing.T) {
+	results := []testResult{
+		{
+			Test:     tests.Test{Name: "passed"},
+			Status:   testPassed,
+			Duration: 1500 * time.Millisecond,
+			Files: map[string]string{
+				"plan": tests.NoChange,
+			},
+		},
+		{
+			Test:     tests.Test{Name: "diffs"},
+			Status:   testHadDiffs,
+			Duration: 2 * time.Second,
+			Files: map[string]string{
+				"new.json": tests.NewFile,
+				"old.json": tests.RemovedFile,
+				"plan":     "-one\n+two\n",
+			},
+		},
+		{
+			Test:     tests.Test{Name: "updated"},
+			Status:   testPassed,
+			Duration: time.Second,
+			Updated:  []string{"plan", "state.json"},
+		},
+		{
+			Test:     tests.Test{Name: "failed"},
+			Status:   testFailed,
+			Duration: time.Second,
+			Err: fmt.Errorf("wrapped: %w", terraform.Error{
+				Command:   "terraform apply",
+				Go:        errors.New("exit status 1"),
+				Terraform: errors.New("Error: invalid configuration"),
+			}),
+		},
+		{
+			Test:     tests.Test{Name: "timeout"},
+			Status:   testTimedOut,
+			Duration: 3 * time.Second,
+			Err:      terraform.TimeoutError{Command: "terraform plan"},
+		},
+	}
+
+	actual, err := xml.MarshalIndent(newJUnitReport(filesTerraform{version: "1.5.0"}, results), "", "  ")
+	if err != nil {
+		t.Fatalf("could not marshal report: %v", err)
+	}
+
+	expected := `<testsuites name="terraform-equivalence-testing (Terraform v1.5.0)" tests="8" failures="3" errors="2">
+  <testsuite name="passed" tests="1" failures="0" errors="0" time="1.500">
+    <testcase name="plan" classname="passed"></testcase>
+  </testsuite>
+  <testsuite name="diffs" tests="3" failures="3" errors="0" time="2.000">
+    <testcase name="new.json" classname="diffs">
+      <failure message="new.json was a new file"></failure>
+    </testcase>
+    <testcase name="old.json" classname="diffs">
+      <failure message="old.json was removed"></failure>
+    </testcase>
+    <testcase name="plan" classname="diffs">
+      <failure message="plan had diffs (-want +got)">-one&#xA;+two&#xA;</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="updated" tests="2" failures="0" errors="0" time="1.000">
+    <testcase name="plan" classname="updated"></testcase>
+    <testcase name="state.json" classname="updated"></testcase>
+  </testsuite>
+  <testsuite name="failed" tests="1" failures="0" errors="1" time="1.000">
+    <testcase name="failed" classname="failed">
+      <error message="wrapped: terraform command (terraform apply) failed (exit status 1) (Error: invalid configuration)" type="failed">Error: invalid configuration</error>
+    </testcase>
+  </testsuite>
+  <testsuite name="timeout" tests="1" failures="0" errors="1" time="3.000">
+    <testcase name="timeout" classname="timeout">
+      <error message="terraform command (terraform plan) timed out" type="timeout"></error>
+    </testcase>
+  </testsuite>
+</testsuites>`
+	if diff := cmp.Diff(expected, string(actual)); len(diff) > 0 {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+}
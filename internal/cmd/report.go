@@ -0,0 +1,151 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-equivalence-testing/internal/terraform"
+	"github.com/hashicorp/terraform-equivalence-testing/internal/tests"
+)
+
+const (
+	fileNew      = "new"
+	fileNoChange = "no_change"
+	fileChanged  = "changed"
+	fileRemoved  = "removed"
+)
+
+// report is the machine-readable summary of a diff command, written to the
+// path given by the --report flag.
+type report struct {
+	TerraformVersion string       `json:"terraform_version"`
+	TerraformBinary  string       `json:"terraform_binary"`
+	Tests            []testReport `json:"tests"`
+}
+
+type testReport struct {
+	Name            string       `json:"name"`
+	Status          string       `json:"status"`
+	DurationSeconds float64      `json:"duration_seconds"`
+	Files           []fileReport `json:"files,omitempty"`
+	Warnings        []string     `json:"warnings,omitempty"`
+	Error           *errorReport `json:"error,omitempty"`
+}
+
+type fileReport struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+	Diff  string `json:"diff,omitempty"`
+
+	// Patch is set instead of Diff for changed JSON files when the diffs are
+	// rendered as JSON Patch documents, so the patch can be extracted from
+	// the report and applied directly.
+	Patch json.RawMessage `json:"patch,omitempty"`
+}
+
+type errorReport struct {
+	Message string `json:"message"`
+
+	// The following fields are only set if the error came from a Terraform
+	// command.
+	Command  string `json:"command,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+}
+
+// newReport returns the report for results. If patches is true, the diffs for
+// JSON files are JSON Patch documents and are embedded into the report as JSON.
+func newReport(tf terraform.Terraform, binary string, patches bool, results []testResult) report {
+	report := report{
+		TerraformVersion: tf.Version(),
+		TerraformBinary:  binary,
+		Tests:            []testReport{},
+	}
+
+	for _, result := range results {
+		test := testReport{
+			Name:            result.Test.Name,
+			Status:          result.Status.String(),
+			DurationSeconds: result.Duration.Seconds(),
+			Files:           newFileReports(result.Files, patches),
+			Warnings:        result.Warnings,
+		}
+
+		if result.Err != nil {
+			test.Error = newErrorReport(result.Err)
+		}
+
+		report.Tests = append(report.Tests, test)
+	}
+	return report
+}
+
+func newFileReports(files map[string]string, patches bool) []fileReport {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var reports []fileReport
+	for _, name := range names {
+		switch diff := files[name]; diff {
+		case tests.NewFile:
+			reports = append(reports, fileReport{Name: name, State: fileNew})
+		case tests.NoChange:
+			reports = append(reports, fileReport{Name: name, State: fileNoChange})
+		case tests.RemovedFile:
+			reports = append(reports, fileReport{Name: name, State: fileRemoved})
+		default:
+			if patches && strings.HasSuffix(name, ".json") && json.Valid([]byte(diff)) {
+				reports = append(reports, fileReport{Name: name, State: fileChanged, Patch: json.RawMessage(diff)})
+				continue
+			}
+			reports = append(reports, fileReport{Name: name, State: fileChanged, Diff: diff})
+		}
+	}
+	return reports
+}
+
+func newErrorReport(err error) *errorReport {
+	report := errorReport{
+		Message: err.Error(),
+	}
+
+	var timeoutErr terraform.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		report.Command = timeoutErr.Command
+	}
+
+	var tfErr terraform.Error
+	if errors.As(err, &tfErr) {
+		report.Command = tfErr.Command
+		if tfErr.Terraform != nil {
+			report.Stderr = tfErr.Terraform.Error()
+		}
+
+		var exitErr *exec.ExitError
+		if errors.As(tfErr.Go, &exitErr) {
+			code := exitErr.ExitCode()
+			report.ExitCode = &code
+		}
+	}
+
+	return &report
+}
+
+// writeReport writes report as indented JSON into the file at target.
+func writeReport(target string, report report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0644)
+}
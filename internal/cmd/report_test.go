@@ -0,0 +1,155 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hashicorp/terraform-equivalence-testing/internal/terraform"
+	"github.com/hashicorp/terraform-equivalence-testing/internal/tests"
+)
+
+// exitError returns the error from a process that exited with code.
+func exitError(t *testing.T, code int) error {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("exit codes require a POSIX shell")
+	}
+
+	err := exec.Command("sh", "-c", fmt.Sprintf("exit %d", code)).Run()
+	if err == nil {
+		t.Fatalf("expected the command to fail")
+	}
+	return err
+}
+
+func TestNewReport(t *testing.T) {
+	results := []testResult{
+		{
+			Test:     tests.Test{Name: "passed"},
+			Status:   testPassed,
+			Duration: 1500 * time.Millisecond,
+			Files: map[string]string{
+				"plan":       tests.NoChange,
+				"state.json": tests.NoChange,
+			},
+		},
+		{
+			Test:     tests.Test{Name: "diffs"},
+			Status:   testHadDiffs,
+			Duration: 2 * time.Second,
+			Files: map[string]string{
+				"new.json":   tests.NewFile,
+				"old.json":   tests.RemovedFile,
+				"plan":       "-one\n+two\n",
+				"state.json": `[{"op": "remove", "path": "/values"}]`,
+			},
+		},
+		{
+			Test:     tests.Test{Name: "failed"},
+			Status:   testFailed,
+			Duration: time.Second,
+			Err: fmt.Errorf("wrapped: %w", terraform.Error{
+				Command:   "terraform apply",
+				Go:        exitError(t, 3),
+				Terraform: errors.New("Error: invalid configuration"),
+			}),
+		},
+		{
+			Test:     tests.Test{Name: "timeout"},
+			Status:   testTimedOut,
+			Duration: 3 * time.Second,
+			Err:      terraform.TimeoutError{Command: "terraform plan"},
+		},
+	}
+
+	actual, err := json.MarshalIndent(newReport(filesTerraform{version: "1.5.0"}, "/usr/bin/terraform", true, results), "", "  ")
+	if err != nil {
+		t.Fatalf("could not marshal report: %v", err)
+	}
+
+	expected := `{
+  "terraform_version": "1.5.0",
+  "terraform_binary": "/usr/bin/terraform",
+  "tests": [
+    {
+      "name": "passed",
+      "status": "passed",
+      "duration_seconds": 1.5,
+      "files": [
+        {
+          "name": "plan",
+          "state": "no_change"
+        },
+        {
+          "name": "state.json",
+          "state": "no_change"
+        }
+      ]
+    },
+    {
+      "name": "diffs",
+      "status": "diffs",
+      "duration_seconds": 2,
+      "files": [
+        {
+          "name": "new.json",
+          "state": "new"
+        },
+        {
+          "name": "old.json",
+          "state": "removed"
+        },
+        {
+          "name": "plan",
+          "state": "changed",
+          "diff": "-one\n+two\n"
+        },
+        {
+          "name": "state.json",
+          "state": "changed",
+          "patch": [
+            {
+              "op": "remove",
+              "path": "/values"
+            }
+          ]
+        }
+      ]
+    },
+    {
+      "name": "failed",
+      "status": "failed",
+      "duration_seconds": 1,
+      "error": {
+        "message": "wrapped: terraform command (terraform apply) failed (exit status 3) (Error: invalid configuration)",
+        "command": "terraform apply",
+        "stderr": "Error: invalid configuration",
+        "exit_code": 3
+      }
+    },
+    {
+      "name": "timeout",
+      "status": "timeout",
+      "duration_seconds": 3,
+      "error": {
+        "message": "terraform command (terraform plan) timed out",
+        "command": "terraform plan"
+      }
+    }
+  ]
+}`
+	if diff := cmp.Diff(expected, string(actual)); len(diff) > 0 {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/terraform-equivalence-testing/internal/terraform"
+	"github.com/hashicorp/terraform-equivalence-testing/internal/tests"
+)
+
+// testStatus is the outcome of a single test case.
+type testStatus int
+
+const (
+	testPassed testStatus = iota
+	testHadDiffs
+	testFailed
+	testTimedOut
+)
+
+func (status testStatus) String() string {
+	switch status {
+	case testPassed:
+		return "passed"
+	case testHadDiffs:
+		return "diffs"
+	case testFailed:
+		return "failed"
+	case testTimedOut:
+		return "timeout"
+	default:
+		return "unknown"
+	}
+}
+
+// testResult records everything we know about a single test case after it
+// was executed.
+type testResult struct {
+	Test     tests.Test
+	Status   testStatus
+	Duration time.Duration
+
+	// Files maps the name of each output file to either the diff for that
+	// file, or one of the tests.NewFile, tests.NoChange or tests.RemovedFile
+	// constants. It is empty unless the test case executed successfully.
+	Files map[string]string
+
+	// Updated lists the names of the golden files written by the update
+	// command, in sorted order.
+	Updated []string
+
+	// Warnings are the problems found with the test specification that
+	// didn't stop the test case from executing, such as ignore_fields entries
+	// that didn't match anything.
+	Warnings []string
+
+	// Err is the error that caused the test case to fail or time out.
+	Err error
+}
+
+// runTests executes run for every test case, using at most parallelism
+// concurrent workers, and returns the results in the same order as
+// testCases.
+//
+// Each invocation of run receives its own cli.Ui. Anything written to it is
+// buffered and only written to the shared ui once the test case has finished,
+// so the output of concurrently executing test cases is never interleaved.
+func runTests(ui cli.Ui, testCases []tests.Test, parallelism int, run func(test tests.Test, ui cli.Ui) testResult) []testResult {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	results := make([]testResult, len(testCases))
+
+	queue := make(chan int)
+	for worker := 0; worker < parallelism; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ix := range queue {
+				buffer := &bufferedUi{}
+
+				start := time.Now()
+				results[ix] = run(testCases[ix], buffer)
+				results[ix].Test = testCases[ix]
+				results[ix].Duration = time.Since(start)
+
+				mutex.Lock()
+				buffer.flush(ui)
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for ix := range testCases {
+		queue <- ix
+	}
+	close(queue)
+
+	wg.Wait()
+	return results
+}
+
+// countStatuses returns the number of results with each testStatus.
+func countStatuses(results []testResult) map[testStatus]int {
+	counts := map[testStatus]int{}
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	return counts
+}
+
+// reportDiffSummary writes the summary of the results of the diff and compare
+// commands to ui, and returns the exit code the command should return.
+func reportDiffSummary(results []testResult, ui cli.Ui) int {
+	counts := countStatuses(results)
+	successfulTests := counts[testPassed] + counts[testHadDiffs]
+	testsWithDiffs := counts[testHadDiffs]
+	failedTests := counts[testFailed]
+	timedOutTests := counts[testTimedOut]
+
+	ui.Output(fmt.Sprintf("Equivalence testing complete."))
+	ui.Output(fmt.Sprintf("\tAttempted %d test(s).", len(results)))
+
+	exitCode := 0
+
+	if successfulTests > 0 {
+		ui.Output(fmt.Sprintf("\t%d test(s) were successful.", successfulTests))
+	}
+
+	if testsWithDiffs > 0 {
+		exitCode = 2 // non-zero exit code to indicate diffs, but different from failed tests
+		ui.Output(fmt.Sprintf("\t%d test(s) had diffs.", testsWithDiffs))
+	}
+
+	if failedTests > 0 {
+		exitCode = 1 // failed tests should have a non-zero exit code
+		ui.Output(fmt.Sprintf("\t%d test(s) failed.", failedTests))
+	}
+
+	if timedOutTests > 0 {
+		exitCode = 1
+		ui.Output(fmt.Sprintf("\t%d test(s) timed out.", timedOutTests))
+	}
+
+	return exitCode
+}
+
+// diffMessages describes each kind of file diff in the output of reportDiffs.
+type diffMessages struct {
+	NewFile     string
+	RemovedFile string
+	Changed     string
+}
+
+var (
+	// goldenDiffMessages describes the diffs between the golden files and the
+	// output of a test case.
+	goldenDiffMessages = diffMessages{
+		NewFile:     "was a new file",
+		RemovedFile: "was removed",
+		Changed:     "had diffs (-want +got)",
+	}
+
+	// binaryDiffMessages describes the diffs between the outputs of the two
+	// binaries being compared.
+	binaryDiffMessages = diffMessages{
+		NewFile:     "was only produced by --binary-b",
+		RemovedFile: "was only produced by --binary-a",
+		Changed:     "had diffs (-binary-a +binary-b)",
+	}
+)
+
+// reportDiffs writes the diff of each file to ui, as returned by
+// tests.TestOutput.ComputeDiff or tests.TestOutput.CompareTo, and returns
+// whether the test case passed or had diffs.
+func reportDiffs(test tests.Test, files map[string]string, messages diffMessages, flags *Flags, ui cli.Ui) testStatus {
+	newFileCount := 0
+	noChangeCount := 0
+	changeCount := 0
+	removedFileCount := 0
+
+	for file, diff := range files {
+		switch diff {
+		case tests.NewFile:
+			newFileCount++
+			ui.Output(fmt.Sprintf("[%s]: %s %s", test.Name, file, messages.NewFile))
+		case tests.RemovedFile:
+			removedFileCount++
+			ui.Output(fmt.Sprintf("[%s]: %s %s", test.Name, file, messages.RemovedFile))
+		case tests.NoChange:
+			noChangeCount++
+			ui.Output(fmt.Sprintf("[%s]: %s had no diffs", test.Name, file))
+		default:
+			changeCount++
+			ui.Output(fmt.Sprintf("[%s]: %s %s:\n%s", test.Name, file, messages.Changed, flags.printableDiff(diff)))
+		}
+	}
+
+	ui.Output(fmt.Sprintf("[%s]: complete\n", test.Name))
+
+	if newFileCount+changeCount+removedFileCount > 0 {
+		return testHadDiffs
+	}
+	return testPassed
+}
+
+// testContext returns the context a test case should be executed with. The
+// context has a deadline if either the test specification or the --timeout
+// flag set a timeout for the test case.
+func testContext(test tests.Test, flags *Flags) (context.Context, context.CancelFunc) {
+	if timeout := test.Specification.TimeoutOrDefault(flags.Timeout); timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// reportRunError writes the error returned by tests.Test.RunWith to ui, and
+// returns the matching testResult.
+func reportRunError(test tests.Test, err error, ui cli.Ui) testResult {
+	var timeoutErr terraform.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		ui.Output(fmt.Sprintf("[%s]: %s", test.Name, timeoutErr))
+		return testResult{Status: testTimedOut, Err: err}
+	}
+
+	var tfErr terraform.Error
+	if errors.As(err, &tfErr) {
+		ui.Output(fmt.Sprintf("[%s]: %s", test.Name, tfErr))
+		return testResult{Status: testFailed, Err: err}
+	}
+
+	ui.Output(fmt.Sprintf("[%s]: unknown error (%v)", test.Name, err))
+	return testResult{Status: testFailed, Err: err}
+}
+
+// reportWarnings writes the warnings for output to ui, and returns them. If
+// the --strict-ignores flag is set, it also returns an error if any ignore
+// entries didn't match anything so the test case can be failed.
+func reportWarnings(test tests.Test, output tests.TestOutput, flags *Flags, ui cli.Ui) ([]string, error) {
+	warnings, err := output.Warnings()
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []string
+	unmatched := 0
+	for _, warning := range warnings {
+		ui.Warn(fmt.Sprintf("[%s]: warning: %s", test.Name, warning))
+		messages = append(messages, warning.String())
+		if warning.Unmatched {
+			unmatched++
+		}
+	}
+
+	if flags.StrictIgnores && unmatched > 0 {
+		return messages, fmt.Errorf("found %d ignore entries that did not match anything with --strict-ignores set", unmatched)
+	}
+	return messages, nil
+}
+
+// bufferedUi is a cli.Ui that records all the messages written to it so they
+// can be written to another cli.Ui later in one go.
+type bufferedUi struct {
+	messages []bufferedMessage
+}
+
+type bufferedMessage struct {
+	write   func(ui cli.Ui, message string)
+	message string
+}
+
+func (ui *bufferedUi) Ask(string) (string, error) {
+	return "", errors.New("cannot ask for input while executing test cases")
+}
+
+func (ui *bufferedUi) AskSecret(string) (string, error) {
+	return "", errors.New("cannot ask for input while executing test cases")
+}
+
+func (ui *bufferedUi) Output(message string) {
+	ui.messages = append(ui.messages, bufferedMessage{write: cli.Ui.Output, message: message})
+}
+
+func (ui *bufferedUi) Info(message string) {
+	ui.messages = append(ui.messages, bufferedMessage{write: cli.Ui.Info, message: message})
+}
+
+func (ui *bufferedUi) Error(message string) {
+	ui.messages = append(ui.messages, bufferedMessage{write: cli.Ui.Error, message: message})
+}
+
+func (ui *bufferedUi) Warn(message string) {
+	ui.messages = append(ui.messages, bufferedMessage{write: cli.Ui.Warn, message: message})
+}
+
+func (ui *bufferedUi) flush(target cli.Ui) {
+	for _, message := range ui.messages {
+		message.write(target, message.message)
+	}
+	ui.messages = nil
+}
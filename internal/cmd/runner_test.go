@@ -0,0 +1,241 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/terraform-equivalence-testing/internal/files"
+	"github.com/hashicorp/terraform-equivalence-testing/internal/terraform"
+	"github.com/hashicorp/terraform-equivalence-testing/internal/tests"
+)
+
+func TestRunTests(t *testing.T) {
+	var testCases []tests.Test
+	positions := map[string]int{}
+	for ix := 0; ix < 20; ix++ {
+		name := fmt.Sprintf("test_%d", ix)
+		testCases = append(testCases, tests.Test{Name: name})
+		positions[name] = ix
+	}
+
+	statuses := []testStatus{testPassed, testHadDiffs, testFailed, testTimedOut}
+
+	ui := cli.NewMockUi()
+	results := runTests(ui, testCases, 4, func(test tests.Test, ui cli.Ui) testResult {
+		ui.Output(test.Name + " started")
+
+		// Make the earlier test cases finish last, so the workers are
+		// guaranteed to overlap.
+		ix := positions[test.Name]
+		time.Sleep(time.Duration(len(testCases)-ix) * time.Millisecond)
+
+		ui.Output(test.Name + " finished")
+		return testResult{Status: statuses[ix%len(statuses)]}
+	})
+
+	if len(results) != len(testCases) {
+		t.Fatalf("expected %d results, but found %d", len(testCases), len(results))
+	}
+	for ix, result := range results {
+		if result.Test.Name != testCases[ix].Name {
+			t.Errorf("expected result %d to be %s, but found %s", ix, testCases[ix].Name, result.Test.Name)
+		}
+		if expected := statuses[ix%len(statuses)]; result.Status != expected {
+			t.Errorf("expected result %d to have status %s, but found %s", ix, expected, result.Status)
+		}
+	}
+
+	expected := map[testStatus]int{testPassed: 5, testHadDiffs: 5, testFailed: 5, testTimedOut: 5}
+	if diff := cmp.Diff(expected, countStatuses(results)); len(diff) > 0 {
+		t.Errorf("unexpected status counts (-want +got):\n%s", diff)
+	}
+
+	// The output of each test case should be written in one block, even
+	// though the test cases executed concurrently.
+	lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	if len(lines) != 2*len(testCases) {
+		t.Fatalf("expected %d lines of output, but found %d", 2*len(testCases), len(lines))
+	}
+	for ix := 0; ix < len(lines); ix += 2 {
+		name := strings.TrimSuffix(lines[ix], " started")
+		if lines[ix+1] != name+" finished" {
+			t.Errorf("expected %q to follow %q, but found %q", name+" finished", lines[ix], lines[ix+1])
+		}
+	}
+}
+
+func TestReportRunError(t *testing.T) {
+	tfErr := terraform.Error{Command: "terraform apply", Go: errors.New("exit status 1")}
+
+	tcs := map[string]struct {
+		err      error
+		status   testStatus
+		expected string
+	}{
+		"terraform": {
+			err:      tfErr,
+			status:   testFailed,
+			expected: "[test]: " + tfErr.Error(),
+		},
+		"wrapped_terraform": {
+			err:      fmt.Errorf("wrapped: %w", tfErr),
+			status:   testFailed,
+			expected: "[test]: " + tfErr.Error(),
+		},
+		"timeout": {
+			err:      fmt.Errorf("wrapped: %w", terraform.TimeoutError{Command: "terraform plan"}),
+			status:   testTimedOut,
+			expected: "[test]: terraform command (terraform plan) timed out",
+		},
+		"unknown": {
+			err:      errors.New("boom"),
+			status:   testFailed,
+			expected: "[test]: unknown error (boom)",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			result := reportRunError(tests.Test{Name: "test"}, tc.err, ui)
+			if result.Status != tc.status {
+				t.Errorf("expected status %s, but found %s", tc.status, result.Status)
+			}
+			if actual := strings.TrimSpace(ui.OutputWriter.String()); actual != tc.expected {
+				t.Errorf("expected output %q, but found %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// filesTerraform is a terraform.Terraform that returns the same files for
+// every test case without executing anything.
+type filesTerraform struct {
+	files   map[string]*files.File
+	version string
+}
+
+func (tf filesTerraform) ExecuteTest(context.Context, string, terraform.Environment, []string, ...terraform.Command) (map[string]*files.File, error) {
+	return tf.files, nil
+}
+
+func (tf filesTerraform) Version() string {
+	return tf.version
+}
+
+func TestReportWarnings(t *testing.T) {
+	tcs := map[string]struct {
+		spec   string
+		strict bool
+		err    bool
+	}{
+		"unmatched": {
+			spec: `{"ignore_fields": {"plan.json": ["missing"]}}`,
+		},
+		"unmatched_strict": {
+			spec:   `{"ignore_fields": {"plan.json": ["missing"]}}`,
+			strict: true,
+			err:    true,
+		},
+		"missing_file_strict": {
+			spec:   `{"ignore_fields": {"state.json": ["values"]}}`,
+			strict: true,
+			err:    true,
+		},
+		"wrong_type_strict": {
+			spec:   `{"ignore_lines": {"plan.json": [{"remove": "^present"}]}}`,
+			strict: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var specification tests.TestSpecification
+			if err := json.Unmarshal([]byte(tc.spec), &specification); err != nil {
+				t.Fatalf("failed to parse specification: %v", err)
+			}
+
+			directory := t.TempDir()
+			if err := os.Mkdir(filepath.Join(directory, "test"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			test := tests.Test{Name: "test", Directory: directory, Specification: specification}
+			output, err := test.RunWith(context.Background(), filesTerraform{
+				files: map[string]*files.File{
+					"plan.json": files.NewJsonFile(map[string]interface{}{"present": true}),
+				},
+				version: "1.5.0",
+			})
+			if err != nil {
+				t.Fatalf("RunWith failed unexpectedly: %v", err)
+			}
+
+			ui := cli.NewMockUi()
+			warnings, err := reportWarnings(test, output, &Flags{StrictIgnores: tc.strict}, ui)
+			if len(warnings) != 1 {
+				t.Errorf("expected one warning, but found %v", warnings)
+			}
+			if tc.err && err == nil {
+				t.Errorf("expected an error with --strict-ignores set")
+			}
+			if !tc.err && err != nil {
+				t.Errorf("expected no error, but found %v", err)
+			}
+		})
+	}
+}
+
+func TestReportDiffSummary(t *testing.T) {
+	tcs := map[string]struct {
+		statuses []testStatus
+		expected int
+	}{
+		"passed": {
+			statuses: []testStatus{testPassed, testPassed},
+			expected: 0,
+		},
+		"diffs": {
+			statuses: []testStatus{testPassed, testHadDiffs},
+			expected: 2,
+		},
+		"failed": {
+			statuses: []testStatus{testHadDiffs, testFailed},
+			expected: 1,
+		},
+		"timeout": {
+			statuses: []testStatus{testHadDiffs, testTimedOut},
+			expected: 1,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var results []testResult
+			for _, status := range tc.statuses {
+				results = append(results, testResult{Status: status})
+			}
+
+			ui := cli.NewMockUi()
+			if exitCode := reportDiffSummary(results, ui); exitCode != tc.expected {
+				t.Errorf("expected exit code %d, but found %d", tc.expected, exitCode)
+			}
+			if !strings.Contains(ui.OutputWriter.String(), fmt.Sprintf("Attempted %d test(s).", len(results))) {
+				t.Errorf("expected the number of attempted tests in the output, but found:\n%s", ui.OutputWriter.String())
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/mitchellh/cli"
@@ -27,7 +28,7 @@ type updateCommand struct {
 
 func (cmd *updateCommand) Help() string {
 	return strings.TrimSpace(`
-Usage: terraform-equivalence-testing update --goldens=examples/example_golden_files --tests=examples/example_test_cases [--binary=terraform] [--filters=complex_resource,simple_resource]
+Usage: terraform-equivalence-testing update --goldens=examples/example_golden_files --tests=examples/example_test_cases [--binary=terraform] [--filters=complex_resource,simple_resource] [--parallelism=1] [--timeout=10m] [--junit=report.xml] [--strict-ignores]
 
 Update the equivalence test golden files.
 
@@ -57,47 +58,82 @@ func (cmd *updateCommand) Run(args []string) int {
 	}
 	cmd.ui.Output(fmt.Sprintf("Found %d test cases in %s\n", len(testCases), flags.TestingFilesDirectory))
 
-	successfulTests := 0
-	failedTests := 0
-
-	for _, test := range testCases {
-		cmd.ui.Output(fmt.Sprintf("[%s]: starting...", test.Name))
-
-		output, err := test.RunWith(tf)
-		if err != nil {
-			failedTests++
-			if tfErr, ok := err.(terraform.Error); ok {
-				cmd.ui.Output(fmt.Sprintf("[%s]: %s", test.Name, tfErr))
-				continue
-			}
-			cmd.ui.Output(fmt.Sprintf("[%s]: unknown error (%v)", test.Name, err))
-			continue
-		}
-
-		cmd.ui.Output(fmt.Sprintf("[%s]: updating golden files...", test.Name))
-
-		if err := output.UpdateGoldenFiles(flags.GoldenFilesDirectory); err != nil {
-			failedTests++
-			cmd.ui.Output(fmt.Sprintf("[%s]: unknown error (%v)", test.Name, err))
-			continue
-		}
+	results := runTests(cmd.ui, testCases, flags.Parallelism, func(test tests.Test, ui cli.Ui) testResult {
+		return cmd.runTest(test, tf, flags, ui)
+	})
 
-		successfulTests++
-		cmd.ui.Output(fmt.Sprintf("[%s]: complete\n", test.Name))
-	}
+	counts := countStatuses(results)
+	successfulTests := counts[testPassed]
+	failedTests := counts[testFailed]
+	timedOutTests := counts[testTimedOut]
 
 	cmd.ui.Output(fmt.Sprintf("Equivalence testing complete."))
 	cmd.ui.Output(fmt.Sprintf("\tAttempted %d test(s).", len(testCases)))
 
+	exitCode := 0
+
 	if successfulTests > 0 {
 		cmd.ui.Output(fmt.Sprintf("\t%d test(s) were successfully updated.", successfulTests))
 	}
+
 	if failedTests > 0 {
+		exitCode = 1
 		cmd.ui.Output(fmt.Sprintf("\t%d test(s) failed to update.", failedTests))
-		return 1
 	}
 
-	return 0
+	if timedOutTests > 0 {
+		exitCode = 1
+		cmd.ui.Output(fmt.Sprintf("\t%d test(s) timed out.", timedOutTests))
+	}
+
+	if len(flags.JUnit) > 0 {
+		if err := writeJUnitReport(flags.JUnit, newJUnitReport(tf, results)); err != nil {
+			cmd.ui.Error(fmt.Sprintf("failed to write JUnit report: %v", err))
+			return 1
+		}
+	}
+
+	return exitCode
+}
+
+func (cmd *updateCommand) runTest(test tests.Test, tf terraform.Terraform, flags *Flags, ui cli.Ui) testResult {
+	ui.Output(fmt.Sprintf("[%s]: starting...", test.Name))
+
+	ctx, cancel := testContext(test, flags)
+	defer cancel()
+
+	output, err := test.RunWith(ctx, tf)
+	if err != nil {
+		return reportRunError(test, err, ui)
+	}
+
+	warnings, err := reportWarnings(test, output, flags, ui)
+	if err != nil {
+		ui.Output(fmt.Sprintf("[%s]: %v", test.Name, err))
+		return testResult{Status: testFailed, Warnings: warnings, Err: err}
+	}
+
+	ui.Output(fmt.Sprintf("[%s]: updating golden files...", test.Name))
+
+	outputFiles, err := output.Files()
+	if err != nil {
+		ui.Output(fmt.Sprintf("[%s]: unknown error (%v)", test.Name, err))
+		return testResult{Status: testFailed, Warnings: warnings, Err: err}
+	}
+
+	var updated []string
+	for name := range outputFiles {
+		updated = append(updated, name)
+	}
+	sort.Strings(updated)
+
+	if err := output.UpdateGoldenFiles(flags.GoldenFilesDirectory); err != nil {
+		ui.Output(fmt.Sprintf("[%s]: unknown error (%v)", test.Name, err))
+		return testResult{Status: testFailed, Warnings: warnings, Err: err}
+	}
+
+	ui.Output(fmt.Sprintf("[%s]: complete\n", test.Name))
+	return testResult{Status: testPassed, Updated: updated, Warnings: warnings}
 }
 
 func (cmd *updateCommand) Synopsis() string {
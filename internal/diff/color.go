@@ -0,0 +1,42 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package diff
+
+import (
+	"strings"
+)
+
+const (
+	red   = "\x1b[31m"
+	green = "\x1b[32m"
+	cyan  = "\x1b[36m"
+	reset = "\x1b[0m"
+)
+
+// Colorize returns diff with ANSI colors added for terminals: removed lines in
+// red, added lines in green, and hunk headers in cyan. It accepts both unified
+// diffs and the diffs produced by cmp.Diff, which mark lines in the same way.
+func Colorize(diff string) string {
+	lines := strings.SplitAfter(diff, "\n")
+	for ix, line := range lines {
+		text := strings.TrimSuffix(line, "\n")
+		newline := line[len(text):]
+
+		var color string
+		switch {
+		case strings.HasPrefix(text, "--- "), strings.HasPrefix(text, "+++ "):
+			continue
+		case strings.HasPrefix(text, "@@"):
+			color = cyan
+		case strings.HasPrefix(text, "-"):
+			color = red
+		case strings.HasPrefix(text, "+"):
+			color = green
+		default:
+			continue
+		}
+		lines[ix] = color + text + reset + newline
+	}
+	return strings.Join(lines, "")
+}
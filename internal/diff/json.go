@@ -0,0 +1,249 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	Add     = "add"
+	Remove  = "remove"
+	Replace = "replace"
+)
+
+// identifier matches the object keys that can be written in a path without
+// quoting them.
+var identifier = regexp.MustCompile(`^[A-Za-z_@][A-Za-z0-9_@-]*$`)
+
+// Path is the location of a value within a JSON document. Each element is
+// either a string key within an object, or an int index within an array.
+type Path []interface{}
+
+// String renders the path in the same style as a jq or JavaScript expression,
+// eg. `resource_changes[3].change.after.tags.Name`. Keys that aren't simple
+// identifiers are quoted, eg. `tags["kubernetes.io/name"]`.
+func (path Path) String() string {
+	if len(path) == 0 {
+		return "(root)"
+	}
+
+	var out strings.Builder
+	for _, element := range path {
+		switch element := element.(type) {
+		case int:
+			fmt.Fprintf(&out, "[%d]", element)
+		case string:
+			if identifier.MatchString(element) {
+				if out.Len() > 0 {
+					out.WriteByte('.')
+				}
+				out.WriteString(element)
+				continue
+			}
+			fmt.Fprintf(&out, "[%s]", encodeValue(element))
+		}
+	}
+	return out.String()
+}
+
+// Pointer renders the path as a JSON Pointer, as defined by RFC 6901.
+func (path Path) Pointer() string {
+	var out strings.Builder
+	for _, element := range path {
+		out.WriteByte('/')
+		switch element := element.(type) {
+		case int:
+			out.WriteString(strconv.Itoa(element))
+		case string:
+			out.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(element))
+		}
+	}
+	return out.String()
+}
+
+// Change is a single difference between two JSON documents.
+//
+// Op is either Add, Remove, or Replace. Old is unset for Add, and New is unset
+// for Remove.
+type Change struct {
+	Op   string
+	Path Path
+	Old  interface{}
+	New  interface{}
+}
+
+func (change Change) String() string {
+	switch change.Op {
+	case Add:
+		return fmt.Sprintf("%s: (absent) -> %s", change.Path, encodeValue(change.New))
+	case Remove:
+		return fmt.Sprintf("%s: %s -> (absent)", change.Path, encodeValue(change.Old))
+	default:
+		return fmt.Sprintf("%s: %s -> %s", change.Path, encodeValue(change.Old), encodeValue(change.New))
+	}
+}
+
+// JsonChanges returns the changes that turn the JSON document oldJson into
+// newJson. Objects and arrays are compared recursively, while any other
+// values are compared using equal.
+//
+// Arrays are compared index by index, so any items added or removed at the
+// end of an array are reported individually, while inserting an item earlier
+// in an array changes every item after it.
+func JsonChanges(oldJson, newJson interface{}, equal func(oldValue, newValue interface{}) bool) []Change {
+	return jsonChanges(nil, oldJson, newJson, equal)
+}
+
+func jsonChanges(path Path, oldJson, newJson interface{}, equal func(oldValue, newValue interface{}) bool) []Change {
+	switch oldNode := oldJson.(type) {
+	case map[string]interface{}:
+		if newNode, ok := newJson.(map[string]interface{}); ok {
+			return objectChanges(path, oldNode, newNode, equal)
+		}
+	case []interface{}:
+		if newNode, ok := newJson.([]interface{}); ok {
+			return arrayChanges(path, oldNode, newNode, equal)
+		}
+	}
+
+	if isContainer(oldJson) || isContainer(newJson) || !equal(oldJson, newJson) {
+		return []Change{{Op: Replace, Path: path, Old: oldJson, New: newJson}}
+	}
+	return nil
+}
+
+func objectChanges(path Path, oldNode, newNode map[string]interface{}, equal func(oldValue, newValue interface{}) bool) []Change {
+	keys := make(map[string]bool)
+	for key := range oldNode {
+		keys[key] = true
+	}
+	for key := range newNode {
+		keys[key] = true
+	}
+
+	var sorted []string
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var changes []Change
+	for _, key := range sorted {
+		child := append(append(Path(nil), path...), key)
+		oldValue, oldOk := oldNode[key]
+		newValue, newOk := newNode[key]
+		switch {
+		case !newOk:
+			changes = append(changes, Change{Op: Remove, Path: child, Old: oldValue})
+		case !oldOk:
+			changes = append(changes, Change{Op: Add, Path: child, New: newValue})
+		default:
+			changes = append(changes, jsonChanges(child, oldValue, newValue, equal)...)
+		}
+	}
+	return changes
+}
+
+func arrayChanges(path Path, oldNode, newNode []interface{}, equal func(oldValue, newValue interface{}) bool) []Change {
+	var changes []Change
+	for ix := 0; ix < len(oldNode) && ix < len(newNode); ix++ {
+		child := append(append(Path(nil), path...), ix)
+		changes = append(changes, jsonChanges(child, oldNode[ix], newNode[ix], equal)...)
+	}
+
+	// Report removed items from the end of the array backwards, so the
+	// indexes in the earlier changes are still valid when applying the
+	// changes in order as a JSON Patch.
+	for ix := len(oldNode) - 1; ix >= len(newNode); ix-- {
+		child := append(append(Path(nil), path...), ix)
+		changes = append(changes, Change{Op: Remove, Path: child, Old: oldNode[ix]})
+	}
+	for ix := len(oldNode); ix < len(newNode); ix++ {
+		child := append(append(Path(nil), path...), ix)
+		changes = append(changes, Change{Op: Add, Path: child, New: newNode[ix]})
+	}
+	return changes
+}
+
+func isContainer(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Paths renders changes as one line per change, keyed by the path of the
+// change. It returns an empty string if there are no changes.
+func Paths(changes []Change) string {
+	var out strings.Builder
+	for _, change := range changes {
+		out.WriteString(change.String())
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
+
+// PatchOperation is a single operation within a JSON Patch document, as
+// defined by RFC 6902.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Patch renders changes as an indented JSON Patch document, which turns the
+// old document into the new document when applied. It returns an empty string
+// if there are no changes.
+func Patch(changes []Change) (string, error) {
+	if len(changes) == 0 {
+		return "", nil
+	}
+
+	var operations []PatchOperation
+	for _, change := range changes {
+		operation := PatchOperation{
+			Op:   change.Op,
+			Path: change.Path.Pointer(),
+		}
+		if change.Op != Remove {
+			operation.Value = change.New
+			if operation.Value == nil {
+				// Make sure a null value is still written out, as
+				// omitempty would otherwise drop it.
+				operation.Value = json.RawMessage("null")
+			}
+		}
+		operations = append(operations, operation)
+	}
+
+	var out strings.Builder
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(operations); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// encodeValue returns value as compact JSON. Unlike json.Marshal, characters
+// such as < and > are not escaped, so placeholders like <masked> are still
+// readable.
+func encodeValue(value interface{}) string {
+	var out strings.Builder
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
@@ -0,0 +1,110 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJsonChanges(t *testing.T) {
+	tcs := map[string]struct {
+		old, new string
+		paths    string
+		patch    string
+	}{
+		"equal": {
+			old:   `{"a": [1, {"b": "c"}]}`,
+			new:   `{"a": [1, {"b": "c"}]}`,
+			paths: "",
+			patch: "",
+		},
+		"replace": {
+			old:   `{"resource_changes": [{"change": {"after": {"tags": {"Name": "a"}}}}]}`,
+			new:   `{"resource_changes": [{"change": {"after": {"tags": {"Name": "b"}}}}]}`,
+			paths: "resource_changes[0].change.after.tags.Name: \"a\" -> \"b\"\n",
+			patch: `[{"op": "replace", "path": "/resource_changes/0/change/after/tags/Name", "value": "b"}]`,
+		},
+		"add_and_remove_keys": {
+			old:   `{"a": 1, "b": {"c": null}}`,
+			new:   `{"b": {}, "d": "<masked>"}`,
+			paths: "a: 1 -> (absent)\nb.c: null -> (absent)\nd: (absent) -> \"<masked>\"\n",
+			patch: `[{"op": "remove", "path": "/a"}, {"op": "remove", "path": "/b/c"}, {"op": "add", "path": "/d", "value": "<masked>"}]`,
+		},
+		"arrays": {
+			old:   `{"list": [1, 2, 3, 4]}`,
+			new:   `{"list": [1, 5], "other": [null]}`,
+			paths: "list[1]: 2 -> 5\nlist[3]: 4 -> (absent)\nlist[2]: 3 -> (absent)\nother: (absent) -> [null]\n",
+			patch: `[{"op": "replace", "path": "/list/1", "value": 5}, {"op": "remove", "path": "/list/3"}, {"op": "remove", "path": "/list/2"}, {"op": "add", "path": "/other", "value": [null]}]`,
+		},
+		"appended": {
+			old:   `[1]`,
+			new:   `[1, 2, null]`,
+			paths: "[1]: (absent) -> 2\n[2]: (absent) -> null\n",
+			patch: `[{"op": "add", "path": "/1", "value": 2}, {"op": "add", "path": "/2", "value": null}]`,
+		},
+		"type_change": {
+			old:   `{"a": {"b": 1}}`,
+			new:   `{"a": [1]}`,
+			paths: "a: {\"b\":1} -> [1]\n",
+			patch: `[{"op": "replace", "path": "/a", "value": [1]}]`,
+		},
+		"root": {
+			old:   `1`,
+			new:   `"one"`,
+			paths: "(root): 1 -> \"one\"\n",
+			patch: `[{"op": "replace", "path": "", "value": "one"}]`,
+		},
+		"quoted_keys": {
+			old:   `{"tags": {"kubernetes.io/name": "a", "~": 1}}`,
+			new:   `{"tags": {"kubernetes.io/name": "b", "~": 2}}`,
+			paths: "tags[\"kubernetes.io/name\"]: \"a\" -> \"b\"\ntags[\"~\"]: 1 -> 2\n",
+			patch: `[{"op": "replace", "path": "/tags/kubernetes.io~1name", "value": "b"}, {"op": "replace", "path": "/tags/~0", "value": 2}]`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var oldJson, newJson interface{}
+			if err := json.Unmarshal([]byte(tc.old), &oldJson); err != nil {
+				t.Fatalf("could not unmarshal old: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tc.new), &newJson); err != nil {
+				t.Fatalf("could not unmarshal new: %v", err)
+			}
+
+			changes := JsonChanges(oldJson, newJson, reflect.DeepEqual)
+
+			if diff := cmp.Diff(tc.paths, Paths(changes)); len(diff) > 0 {
+				t.Errorf("unexpected paths (-want +got):\n%s", diff)
+			}
+
+			patch, err := Patch(changes)
+			if err != nil {
+				t.Fatalf("Patch failed unexpectedly: %v", err)
+			}
+
+			if len(tc.patch) == 0 {
+				if len(patch) > 0 {
+					t.Errorf("expected no patch, but found %s", patch)
+				}
+				return
+			}
+
+			var expected, actual interface{}
+			if err := json.Unmarshal([]byte(tc.patch), &expected); err != nil {
+				t.Fatalf("could not unmarshal expected patch: %v", err)
+			}
+			if err := json.Unmarshal([]byte(patch), &actual); err != nil {
+				t.Fatalf("could not unmarshal patch: %v", err)
+			}
+			if diff := cmp.Diff(expected, actual); len(diff) > 0 {
+				t.Errorf("unexpected patch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
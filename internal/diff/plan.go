@@ -0,0 +1,225 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// planChangeFields are the fields of a change within plan.json that are
+// compared as a whole, because a summary of the old and new values is more
+// useful than the individual differences between them.
+var planChangeFields = []string{"actions", "replace_paths"}
+
+// Plan returns a summary of the differences between two plan.json files,
+// produced by `terraform show -json`, grouped by the address of each resource
+// and output. Values are compared using equal. It returns an empty string if
+// there are no differences.
+//
+// For each resource in resource_changes, the summary reports whether it was
+// added or removed from the plan, and otherwise any changes to its actions,
+// replace_paths, before and after values, and any other fields. The changes to
+// output_changes are reported in the same way, using the `output.` prefix.
+// Any other top-level fields in the plan, such as planned_values, largely
+// repeat the resource changes, so only the number of changes to each of them
+// is reported.
+func Plan(oldJson, newJson interface{}, equal func(oldValue, newValue interface{}) bool) string {
+	oldPlan, oldOk := oldJson.(map[string]interface{})
+	newPlan, newOk := newJson.(map[string]interface{})
+	if !oldOk || !newOk {
+		// This isn't a plan, so just fall back to the regular paths.
+		return Paths(JsonChanges(oldJson, newJson, equal))
+	}
+
+	var lines []string
+	lines = append(lines, changesByAddress(
+		addressedResources(oldPlan["resource_changes"]),
+		addressedResources(newPlan["resource_changes"]),
+		"",
+		equal)...)
+	lines = append(lines, changesByAddress(
+		addressedObjects(oldPlan["output_changes"]),
+		addressedObjects(newPlan["output_changes"]),
+		"output.",
+		equal)...)
+	lines = append(lines, otherChanges(oldPlan, newPlan, []string{"resource_changes", "output_changes"}, equal)...)
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// addressedResources indexes a list of resource changes, or resource
+// instances, by their address. Deposed objects share the address of the
+// current object, so the deposed key is added to their address. Resource
+// changes in plan.json hold the key in `deposed`, while resource instances in
+// state.json hold it in `deposed_key`.
+func addressedResources(value interface{}) map[string]map[string]interface{} {
+	list, _ := value.([]interface{})
+	ret := make(map[string]map[string]interface{})
+	for ix, item := range list {
+		resource, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		address, ok := resource["address"].(string)
+		if !ok {
+			address = fmt.Sprintf("[%d]", ix)
+		}
+		for _, key := range []string{"deposed", "deposed_key"} {
+			if deposed, ok := resource[key].(string); ok {
+				address = fmt.Sprintf("%s (deposed %s)", address, deposed)
+				break
+			}
+		}
+		ret[address] = resource
+	}
+	return ret
+}
+
+// addressedObjects indexes a JSON object of objects by their keys.
+func addressedObjects(value interface{}) map[string]map[string]interface{} {
+	object, _ := value.(map[string]interface{})
+	ret := make(map[string]map[string]interface{})
+	for key, item := range object {
+		if item, ok := item.(map[string]interface{}); ok {
+			ret[key] = item
+		}
+	}
+	return ret
+}
+
+func changesByAddress(oldObjects, newObjects map[string]map[string]interface{}, prefix string, equal func(oldValue, newValue interface{}) bool) []string {
+	var lines []string
+	for _, address := range sortedKeys(oldObjects, newObjects) {
+		oldObject, oldOk := oldObjects[address]
+		newObject, newOk := newObjects[address]
+		name := prefix + address
+
+		switch {
+		case !newOk:
+			lines = append(lines, fmt.Sprintf("%s: removed%s", name, describeActions(oldObject)))
+		case !oldOk:
+			lines = append(lines, fmt.Sprintf("%s: added%s", name, describeActions(newObject)))
+		default:
+			for _, change := range objectChangeSummary(oldObject, newObject, equal) {
+				lines = append(lines, fmt.Sprintf("%s: %s", name, change))
+			}
+		}
+	}
+	return lines
+}
+
+// describeActions returns the actions of a resource or output change, if it
+// has any.
+func describeActions(object map[string]interface{}) string {
+	change, ok := object["change"].(map[string]interface{})
+	if !ok {
+		// Output changes don't have a nested change object.
+		change = object
+	}
+	if actions, ok := change["actions"]; ok {
+		return fmt.Sprintf(" with actions %s", encodeValue(actions))
+	}
+	return ""
+}
+
+// objectChangeSummary returns the differences between two resource changes,
+// or two output changes. The fields of the nested change object are reported
+// without the `change.` prefix.
+func objectChangeSummary(oldObject, newObject map[string]interface{}, equal func(oldValue, newValue interface{}) bool) []string {
+	oldChange, oldOk := oldObject["change"].(map[string]interface{})
+	newChange, newOk := newObject["change"].(map[string]interface{})
+	if !oldOk || !newOk {
+		// Output changes don't have a nested change object.
+		oldChange, newChange = oldObject, newObject
+	} else {
+		oldObject = withoutKeys(oldObject, "change")
+		newObject = withoutKeys(newObject, "change")
+	}
+
+	var lines []string
+	for _, field := range planChangeFields {
+		oldValue, oldOk := oldChange[field]
+		newValue, newOk := newChange[field]
+		if oldOk == newOk && len(JsonChanges(oldValue, newValue, equal)) == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s -> %s", field, describe(oldValue, oldOk), describe(newValue, newOk)))
+	}
+
+	for _, change := range JsonChanges(withoutKeys(oldChange, planChangeFields...), withoutKeys(newChange, planChangeFields...), equal) {
+		lines = append(lines, change.String())
+	}
+
+	if oldOk && newOk {
+		for _, change := range JsonChanges(oldObject, newObject, equal) {
+			lines = append(lines, change.String())
+		}
+	}
+	return lines
+}
+
+// otherChanges summarizes the number of changes to each top-level field of two
+// JSON objects, except for the skipped fields.
+func otherChanges(oldObject, newObject map[string]interface{}, skip []string, equal func(oldValue, newValue interface{}) bool) []string {
+	oldObject = withoutKeys(oldObject, skip...)
+	newObject = withoutKeys(newObject, skip...)
+
+	var lines []string
+	for _, key := range sortedKeys(oldObject, newObject) {
+		oldValue, oldOk := oldObject[key]
+		newValue, newOk := newObject[key]
+		switch {
+		case !newOk:
+			lines = append(lines, fmt.Sprintf("%s: removed", Path{key}))
+		case !oldOk:
+			lines = append(lines, fmt.Sprintf("%s: added", Path{key}))
+		default:
+			if changes := JsonChanges(oldValue, newValue, equal); len(changes) > 0 {
+				lines = append(lines, fmt.Sprintf("%s: %d change(s)", Path{key}, len(changes)))
+			}
+		}
+	}
+	return lines
+}
+
+func describe(value interface{}, ok bool) string {
+	if !ok {
+		return "(absent)"
+	}
+	return encodeValue(value)
+}
+
+// withoutKeys returns a shallow copy of object without the given keys.
+func withoutKeys(object map[string]interface{}, keys ...string) map[string]interface{} {
+	ret := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		ret[key] = value
+	}
+	for _, key := range keys {
+		delete(ret, key)
+	}
+	return ret
+}
+
+func sortedKeys[T any](objects ...map[string]T) []string {
+	keys := make(map[string]bool)
+	for _, object := range objects {
+		for key := range object {
+			keys[key] = true
+		}
+	}
+
+	var sorted []string
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
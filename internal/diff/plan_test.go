@@ -0,0 +1,80 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	tcs := map[string]struct {
+		old, new string
+		expected string
+	}{
+		"equal": {
+			old:      `{"resource_changes": [{"address": "a", "change": {"actions": ["create"]}}]}`,
+			new:      `{"resource_changes": [{"address": "a", "change": {"actions": ["create"]}}]}`,
+			expected: "",
+		},
+		"actions": {
+			old:      `{"resource_changes": [{"address": "random_pet.a", "change": {"actions": ["update"]}}]}`,
+			new:      `{"resource_changes": [{"address": "random_pet.a", "change": {"actions": ["delete", "create"], "replace_paths": [["length"]]}}]}`,
+			expected: "random_pet.a: actions: [\"update\"] -> [\"delete\",\"create\"]\nrandom_pet.a: replace_paths: (absent) -> [[\"length\"]]\n",
+		},
+		"attributes": {
+			old:      `{"resource_changes": [{"address": "a", "action_reason": "one", "change": {"actions": ["update"], "before": {"id": "x"}, "after": {"id": "x", "tags": {"Name": "a"}}}}]}`,
+			new:      `{"resource_changes": [{"address": "a", "action_reason": "two", "change": {"actions": ["update"], "before": {"id": "x"}, "after": {"id": "x", "tags": {"Name": "b"}}}}]}`,
+			expected: "a: after.tags.Name: \"a\" -> \"b\"\na: action_reason: \"one\" -> \"two\"\n",
+		},
+		"added_and_removed": {
+			old:      `{"resource_changes": [{"address": "b", "change": {"actions": ["delete"]}}, {"address": "c"}]}`,
+			new:      `{"resource_changes": [{"address": "a", "change": {"actions": ["create"]}}, {"address": "c"}]}`,
+			expected: "a: added with actions [\"create\"]\nb: removed with actions [\"delete\"]\n",
+		},
+		"reordered": {
+			old:      `{"resource_changes": [{"address": "a"}, {"address": "b"}]}`,
+			new:      `{"resource_changes": [{"address": "b"}, {"address": "a"}]}`,
+			expected: "",
+		},
+		"deposed": {
+			old:      `{"resource_changes": [{"address": "a", "change": {"actions": ["create"]}}]}`,
+			new:      `{"resource_changes": [{"address": "a", "change": {"actions": ["create"]}}, {"address": "a", "deposed": "00000001", "change": {"actions": ["delete"]}}]}`,
+			expected: "a (deposed 00000001): added with actions [\"delete\"]\n",
+		},
+		"outputs": {
+			old:      `{"output_changes": {"name": {"actions": ["create"], "after": "a"}, "gone": {"actions": ["create"]}}}`,
+			new:      `{"output_changes": {"name": {"actions": ["update"], "after": "b"}}}`,
+			expected: "output.gone: removed with actions [\"create\"]\noutput.name: actions: [\"create\"] -> [\"update\"]\noutput.name: after: \"a\" -> \"b\"\n",
+		},
+		"other_fields": {
+			old:      `{"planned_values": {"root_module": {"resources": [1, 2]}}, "errored": false}`,
+			new:      `{"planned_values": {"root_module": {"resources": [3]}}, "variables": {}}`,
+			expected: "errored: removed\nplanned_values: 2 change(s)\nvariables: added\n",
+		},
+		"not_a_plan": {
+			old:      `[1]`,
+			new:      `[2]`,
+			expected: "[0]: 1 -> 2\n",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var oldJson, newJson interface{}
+			if err := json.Unmarshal([]byte(tc.old), &oldJson); err != nil {
+				t.Fatalf("could not unmarshal old: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tc.new), &newJson); err != nil {
+				t.Fatalf("could not unmarshal new: %v", err)
+			}
+
+			actual := Plan(oldJson, newJson, reflect.DeepEqual)
+			if actual != tc.expected {
+				t.Errorf("expected %q but found %q", tc.expected, actual)
+			}
+		})
+	}
+}
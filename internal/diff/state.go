@@ -0,0 +1,68 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package diff
+
+import (
+	"strings"
+)
+
+// State returns a summary of the differences between two state.json files,
+// produced by `terraform show -json`, grouped by the address of each resource
+// and output. Values are compared using equal. It returns an empty string if
+// there are no differences.
+//
+// The resources in the root module and all its child modules are matched by
+// their addresses, so the order resources and modules are listed in doesn't
+// matter. For each resource, the summary reports whether it was added or
+// removed from the state, and otherwise any changes to its attributes. The
+// outputs are reported in the same way, using the `output.` prefix, and only
+// the number of changes to any other top-level fields is reported.
+func State(oldJson, newJson interface{}, equal func(oldValue, newValue interface{}) bool) string {
+	oldState, oldOk := oldJson.(map[string]interface{})
+	newState, newOk := newJson.(map[string]interface{})
+	if !oldOk || !newOk {
+		// This isn't a state, so just fall back to the regular paths.
+		return Paths(JsonChanges(oldJson, newJson, equal))
+	}
+
+	oldValues, _ := oldState["values"].(map[string]interface{})
+	newValues, _ := newState["values"].(map[string]interface{})
+
+	var lines []string
+	lines = append(lines, changesByAddress(
+		addressedResources(moduleResources(oldValues["root_module"])),
+		addressedResources(moduleResources(newValues["root_module"])),
+		"",
+		equal)...)
+	lines = append(lines, changesByAddress(
+		addressedObjects(oldValues["outputs"]),
+		addressedObjects(newValues["outputs"]),
+		"output.",
+		equal)...)
+	lines = append(lines, otherChanges(oldState, newState, []string{"values"}, equal)...)
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// moduleResources returns the resources of a module within state.json, along
+// with the resources of all its child modules.
+func moduleResources(value interface{}) []interface{} {
+	module, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	// Copy the resources, so appending the child modules can't modify the
+	// original array.
+	list, _ := module["resources"].([]interface{})
+	resources := append([]interface{}(nil), list...)
+	children, _ := module["child_modules"].([]interface{})
+	for _, child := range children {
+		resources = append(resources, moduleResources(child)...)
+	}
+	return resources
+}
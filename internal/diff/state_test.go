@@ -0,0 +1,87 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestState(t *testing.T) {
+	tcs := map[string]struct {
+		old, new string
+		expected string
+	}{
+		"equal": {
+			old:      `{"values": {"root_module": {"resources": [{"address": "a", "values": {"id": "x"}}]}}}`,
+			new:      `{"values": {"root_module": {"resources": [{"address": "a", "values": {"id": "x"}}]}}}`,
+			expected: "",
+		},
+		"attributes": {
+			old:      `{"values": {"root_module": {"resources": [{"address": "random_pet.a", "values": {"length": 1, "tags": {"Name": "a"}}}]}}}`,
+			new:      `{"values": {"root_module": {"resources": [{"address": "random_pet.a", "values": {"length": 2, "tags": {}}}]}}}`,
+			expected: "random_pet.a: values.length: 1 -> 2\nrandom_pet.a: values.tags.Name: \"a\" -> (absent)\n",
+		},
+		"added_and_removed": {
+			old:      `{"values": {"root_module": {"resources": [{"address": "b"}, {"address": "c"}]}}}`,
+			new:      `{"values": {"root_module": {"resources": [{"address": "a"}, {"address": "c"}]}}}`,
+			expected: "a: added\nb: removed\n",
+		},
+		"child_modules": {
+			old: `{"values": {"root_module": {"child_modules": [
+				{"address": "module.one", "resources": [{"address": "module.one.a", "values": {"id": 1}}]},
+				{"address": "module.two", "resources": [{"address": "module.two.a", "values": {"id": 2}}]}
+			]}}}`,
+			new: `{"values": {"root_module": {"child_modules": [
+				{"address": "module.two", "resources": [{"address": "module.two.a", "values": {"id": 3}}]},
+				{"address": "module.one", "resources": [{"address": "module.one.a", "values": {"id": 1}}],
+				 "child_modules": [{"address": "module.one.module.nested", "resources": [{"address": "module.one.module.nested.a"}]}]}
+			]}}}`,
+			expected: "module.one.module.nested.a: added\nmodule.two.a: values.id: 2 -> 3\n",
+		},
+		"reordered": {
+			old:      `{"values": {"root_module": {"resources": [{"address": "a"}, {"address": "b"}]}}}`,
+			new:      `{"values": {"root_module": {"resources": [{"address": "b"}, {"address": "a"}]}}}`,
+			expected: "",
+		},
+		"deposed": {
+			old:      `{"values": {"root_module": {"resources": [{"address": "a", "values": {"id": "x"}}, {"address": "a", "deposed_key": "00000001", "values": {"id": "y"}}]}}}`,
+			new:      `{"values": {"root_module": {"resources": [{"address": "a", "deposed_key": "00000001", "values": {"id": "z"}}, {"address": "a", "values": {"id": "x"}}]}}}`,
+			expected: "a (deposed 00000001): values.id: \"y\" -> \"z\"\n",
+		},
+		"outputs": {
+			old:      `{"values": {"outputs": {"name": {"value": "a", "sensitive": false}, "gone": {"value": 1}}}}`,
+			new:      `{"values": {"outputs": {"name": {"value": "b", "sensitive": false}}}}`,
+			expected: "output.gone: removed\noutput.name: value: \"a\" -> \"b\"\n",
+		},
+		"other_fields": {
+			old:      `{"format_version": "1.0", "values": {}}`,
+			new:      `{"format_version": "1.1"}`,
+			expected: "format_version: 1 change(s)\n",
+		},
+		"not_a_state": {
+			old:      `[1]`,
+			new:      `[2]`,
+			expected: "[0]: 1 -> 2\n",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var oldJson, newJson interface{}
+			if err := json.Unmarshal([]byte(tc.old), &oldJson); err != nil {
+				t.Fatalf("could not unmarshal old: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tc.new), &newJson); err != nil {
+				t.Fatalf("could not unmarshal new: %v", err)
+			}
+
+			actual := State(oldJson, newJson, reflect.DeepEqual)
+			if actual != tc.expected {
+				t.Errorf("expected %q but found %q", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines included around each change
+// in a unified diff, unless told otherwise.
+const DefaultContext = 3
+
+// maxEdits limits the number of edits the diff algorithm searches for before
+// giving up and reporting the remaining lines as entirely replaced. This
+// bounds the time and memory spent on files that are completely different.
+const maxEdits = 2048
+
+type operation int
+
+const (
+	equal operation = iota
+	remove
+	insert
+)
+
+type edit struct {
+	op   operation
+	line string
+}
+
+// Unified returns a line-based unified diff from oldText to newText, labelled
+// with oldName and newName, and with context unchanged lines around each
+// change. It returns an empty string if the texts are equal.
+func Unified(oldName, newName, oldText, newText string, context int) string {
+	if oldText == newText {
+		return ""
+	}
+	if context < 0 {
+		context = 0
+	}
+
+	edits := lineEdits(splitLines(oldText), splitLines(newText))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n", oldName)
+	fmt.Fprintf(&out, "+++ %s\n", newName)
+
+	// oldLine and newLine track the line numbers, starting from 1, of the
+	// next line in each text.
+	oldLine, newLine := 1, 1
+	for ix := 0; ix < len(edits); {
+		if edits[ix].op == equal {
+			oldLine++
+			newLine++
+			ix++
+			continue
+		}
+
+		// We've found a change, so include up to context lines before it.
+		start := ix
+		for start > 0 && ix-start < context && edits[start-1].op == equal {
+			start--
+		}
+		oldStart, newStart := oldLine-(ix-start), newLine-(ix-start)
+
+		// Then extend the hunk until there are more than 2*context equal
+		// lines in a row, which would separate it from the next change.
+		end := ix
+		for end < len(edits) {
+			if edits[end].op != equal {
+				end++
+				continue
+			}
+
+			run := end
+			for run < len(edits) && edits[run].op == equal {
+				run++
+			}
+			if run == len(edits) || run-end > 2*context {
+				end += min(run-end, context)
+				break
+			}
+			end = run
+		}
+
+		oldCount, newCount := 0, 0
+		var hunk strings.Builder
+		for _, edit := range edits[start:end] {
+			switch edit.op {
+			case equal:
+				oldCount++
+				newCount++
+				writeLine(&hunk, ' ', edit.line)
+			case remove:
+				oldCount++
+				writeLine(&hunk, '-', edit.line)
+			case insert:
+				newCount++
+				writeLine(&hunk, '+', edit.line)
+			}
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		out.WriteString(hunk.String())
+
+		oldLine, newLine = oldStart+oldCount, newStart+newCount
+		ix = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the start and length of a hunk. Empty ranges start at the
+// line before the change, as in GNU diff.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func writeLine(out *strings.Builder, prefix byte, line string) {
+	out.WriteByte(prefix)
+	if strings.HasSuffix(line, "\n") {
+		out.WriteString(line)
+		return
+	}
+	out.WriteString(line)
+	out.WriteString("\n\\ No newline at end of file\n")
+}
+
+// splitLines splits text into lines, keeping the line endings so a missing
+// newline at the end of the text is still reported as a difference.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineEdits returns the edits that turn a into b, using Myers' algorithm to
+// find the shortest edit script.
+func lineEdits(a, b []string) []edit {
+	// Trim the common prefix and suffix first, as the changes between golden
+	// files are usually small compared to the files themselves.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []edit
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{op: equal, line: line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{op: equal, line: line})
+	}
+	return edits
+}
+
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+
+	// v[offset+k] holds the furthest x reached on diagonal k. We keep a copy
+	// of the diagonals -d to d of v for every number of edits d, so we can
+	// walk back through them to recover the edits once we reach the end of
+	// both texts.
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+
+	// The texts are too different to be worth searching any further, so
+	// report them as entirely replaced.
+	var edits []edit
+	for _, line := range a {
+		edits = append(edits, edit{op: remove, line: line})
+	}
+	for _, line := range b {
+		edits = append(edits, edit{op: insert, line: line})
+	}
+	return edits
+}
+
+// backtrack walks back through the trace recorded by myers, where trace[d]
+// holds the diagonals -d to d as they were before the dth edit.
+func backtrack(a, b []string, trace [][]int) []edit {
+	x, y := len(a), len(b)
+
+	var edits []edit
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[d+k-1] < v[d+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		var prevX int
+		if d > 0 {
+			prevX = v[d+prevK]
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{op: equal, line: a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{op: insert, line: b[y-1]})
+			} else {
+				edits = append(edits, edit{op: remove, line: a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	// We walked backwards, so reverse the edits.
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
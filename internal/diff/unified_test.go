@@ -0,0 +1,189 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func lines(from, to int) string {
+	var out strings.Builder
+	for ix := from; ix <= to; ix++ {
+		fmt.Fprintf(&out, "line %d\n", ix)
+	}
+	return out.String()
+}
+
+func TestUnified(t *testing.T) {
+	tcs := map[string]struct {
+		old, new string
+		context  int
+		expected string
+	}{
+		"equal": {
+			old:      "one\ntwo\n",
+			new:      "one\ntwo\n",
+			context:  3,
+			expected: "",
+		},
+		"changed_line": {
+			old:     lines(1, 10),
+			new:     strings.Replace(lines(1, 10), "line 5\n", "line five\n", 1),
+			context: 2,
+			expected: `--- a
++++ b
+@@ -3,5 +3,5 @@
+ line 3
+ line 4
+-line 5
++line five
+ line 6
+ line 7
+`,
+		},
+		"separate_hunks": {
+			old:     lines(1, 20),
+			new:     strings.Replace(strings.Replace(lines(1, 20), "line 2\n", "", 1), "line 18\n", "line 18\nline 18.5\n", 1),
+			context: 1,
+			expected: `--- a
++++ b
+@@ -1,3 +1,2 @@
+ line 1
+-line 2
+ line 3
+@@ -18,2 +17,3 @@
+ line 18
++line 18.5
+ line 19
+`,
+		},
+		"merged_hunks": {
+			old:     lines(1, 6),
+			new:     strings.Replace(strings.Replace(lines(1, 6), "line 2\n", "line two\n", 1), "line 5\n", "line five\n", 1),
+			context: 1,
+			expected: `--- a
++++ b
+@@ -1,6 +1,6 @@
+ line 1
+-line 2
++line two
+ line 3
+ line 4
+-line 5
++line five
+ line 6
+`,
+		},
+		"no_context": {
+			old:     lines(1, 3),
+			new:     lines(1, 2) + "line 3.5\n" + lines(3, 3),
+			context: 0,
+			expected: `--- a
++++ b
+@@ -2,0 +3 @@
++line 3.5
+`,
+		},
+		"empty_old": {
+			old:     "",
+			new:     "one\n",
+			context: 3,
+			expected: `--- a
++++ b
+@@ -0,0 +1 @@
++one
+`,
+		},
+		"missing_newline": {
+			old:     "one\ntwo\n",
+			new:     "one\ntwo",
+			context: 3,
+			expected: `--- a
++++ b
+@@ -1,2 +1,2 @@
+ one
+-two
++two
+\ No newline at end of file
+`,
+		},
+		"completely_different": {
+			old:     "one\ntwo\n",
+			new:     "three\n",
+			context: 3,
+			expected: `--- a
++++ b
+@@ -1,2 +1 @@
+-one
+-two
++three
+`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			actual := Unified("a", "b", tc.old, tc.new, tc.context)
+			if diff := cmp.Diff(tc.expected, actual); len(diff) > 0 {
+				t.Errorf("unexpected diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnified_TooManyEdits(t *testing.T) {
+	var old, new strings.Builder
+	for ix := 0; ix < maxEdits; ix++ {
+		fmt.Fprintf(&old, "old %d\n", ix)
+		fmt.Fprintf(&new, "new %d\n", ix)
+	}
+
+	actual := Unified("a", "b", old.String(), new.String(), 3)
+	if !strings.HasPrefix(actual, fmt.Sprintf("--- a\n+++ b\n@@ -1,%d +1,%d @@\n", maxEdits, maxEdits)) {
+		t.Errorf("expected the whole file to be replaced, but found:\n%s", actual[:100])
+	}
+}
+
+func TestColorize(t *testing.T) {
+	actual := Colorize("--- a\n+++ b\n@@ -1 +1 @@\n-one\n+two\n same\n")
+	expected := "--- a\n+++ b\n" + cyan + "@@ -1 +1 @@" + reset + "\n" + red + "-one" + reset + "\n" + green + "+two" + reset + "\n same\n"
+	if actual != expected {
+		t.Errorf("expected %q but found %q", expected, actual)
+	}
+}
+
+func TestLineEdits(t *testing.T) {
+	// Every edit script should rebuild both of the original texts, whatever
+	// the texts are.
+	words := []string{"a\n", "b\n", "c\n", "d\n"}
+	random := rand.New(rand.NewSource(0))
+	for ix := 0; ix < 500; ix++ {
+		var a, b []string
+		for jx := random.Intn(12); jx > 0; jx-- {
+			a = append(a, words[random.Intn(len(words))])
+		}
+		for jx := random.Intn(12); jx > 0; jx-- {
+			b = append(b, words[random.Intn(len(words))])
+		}
+
+		var oldLines, newLines []string
+		for _, edit := range lineEdits(a, b) {
+			if edit.op != insert {
+				oldLines = append(oldLines, edit.line)
+			}
+			if edit.op != remove {
+				newLines = append(newLines, edit.line)
+			}
+		}
+
+		if !cmp.Equal(a, oldLines) || !cmp.Equal(b, newLines) {
+			t.Fatalf("edits for %q -> %q rebuilt %q -> %q", a, b, oldLines, newLines)
+		}
+	}
+}
@@ -4,7 +4,10 @@
 package files
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"path/filepath"
 )
 
@@ -15,8 +18,8 @@ const (
 
 func NewFile(file string, contents []byte) (*File, error) {
 	if filepath.Ext(file) == ".json" {
-		var data interface{}
-		if err := json.Unmarshal(contents, &data); err != nil {
+		data, err := DecodeJson(contents)
+		if err != nil {
 			return nil, err
 		}
 		return NewJsonFile(data), nil
@@ -24,6 +27,25 @@ func NewFile(file string, contents []byte) (*File, error) {
 	return NewRawFile(string(contents)), nil
 }
 
+// DecodeJson decodes contents in the same way as json.Unmarshal, except numbers
+// are decoded as json.Number instead of float64. This keeps the exact text of
+// every number, so numbers are written back into the golden files unchanged and
+// can be compared exactly if required.
+func DecodeJson(contents []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.UseNumber()
+
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("invalid character after top-level value")
+	}
+	return data, nil
+}
+
 func NewRawFile(contents string) *File {
 	return &File{
 		contents: contents,
@@ -0,0 +1,260 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	Equal          = "eq"
+	NotEqual       = "ne"
+	Exists         = "exists"
+	Absent         = "absent"
+	In             = "in"
+	Matches        = "regex"
+	Prefix         = "prefix"
+	LessThan       = "lt"
+	LessOrEqual    = "le"
+	GreaterThan    = "gt"
+	GreaterOrEqual = "ge"
+)
+
+// Filter represents a filter that should be validated before a Field is
+// stripped.
+//
+// The Path is followed from the value being filtered, and the value found at
+// the end of it is compared against Value using the Operator. If Operator is
+// empty, the values are compared for equality.
+//
+//   - eq and ne compare any JSON values, including objects and arrays. A value
+//     that is absent is never equal or not equal to anything, and a null value
+//     is never equal to anything, so a filter without a Value never matches.
+//   - exists and absent check whether the Path exists at all, and ignore Value.
+//   - in checks whether the value equals any entry in Value, which must be a
+//     list. Like eq, it never matches a null value.
+//   - regex and prefix check whether the value is a string matching the
+//     regular expression in Value, or starting with the string in Value.
+//   - lt, le, gt, and ge check whether the value is a number less than, less
+//     than or equal to, greater than, or greater than or equal to Value.
+//
+// If And or Or are set, the filter instead matches if all, or any, of the
+// nested filters match. The nested filters are evaluated relative to the
+// value found at Path, and Operator and Value are ignored.
+type Filter struct {
+	Path     []string    `json:"path"`
+	Operator string      `json:"operator"`
+	Value    interface{} `json:"value"`
+
+	And []Filter `json:"and"`
+	Or  []Filter `json:"or"`
+}
+
+func (f *Filter) UnmarshalJSON(data []byte) error {
+	type filter Filter
+	if err := json.Unmarshal(data, (*filter)(f)); err != nil {
+		return err
+	}
+	return f.validate()
+}
+
+func (f Filter) validate() error {
+	switch f.Operator {
+	case "", Equal, NotEqual, Exists, Absent:
+		return nil
+	case In:
+		if _, ok := f.Value.([]interface{}); !ok {
+			return fmt.Errorf("the %s operator requires a list value", f.Operator)
+		}
+	case Matches:
+		pattern, ok := f.Value.(string)
+		if !ok {
+			return fmt.Errorf("the %s operator requires a string value", f.Operator)
+		}
+		if _, err := compileRegex(pattern); err != nil {
+			return fmt.Errorf("invalid regular expression %s: %v", pattern, err)
+		}
+	case Prefix:
+		if _, ok := f.Value.(string); !ok {
+			return fmt.Errorf("the %s operator requires a string value", f.Operator)
+		}
+	case LessThan, LessOrEqual, GreaterThan, GreaterOrEqual:
+		if _, ok := number(f.Value); !ok {
+			return fmt.Errorf("the %s operator requires a number value", f.Operator)
+		}
+	default:
+		return fmt.Errorf("unrecognized filter operator: %s", f.Operator)
+	}
+	return nil
+}
+
+func (f Filter) matches(data interface{}) bool {
+	value, ok := resolve(f.Path, data)
+
+	if len(f.And) > 0 || len(f.Or) > 0 {
+		if !ok {
+			return false
+		}
+		for _, nested := range f.And {
+			if !nested.matches(value) {
+				return false
+			}
+		}
+		if len(f.Or) == 0 {
+			return true
+		}
+		for _, nested := range f.Or {
+			if nested.matches(value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch f.Operator {
+	case Exists:
+		return ok
+	case Absent:
+		return !ok
+	}
+
+	if !ok {
+		return false
+	}
+
+	switch f.Operator {
+	case "", Equal:
+		return value != nil && equal(f.Value, value)
+	case NotEqual:
+		return !equal(f.Value, value)
+	case In:
+		if value == nil {
+			return false
+		}
+		targets, _ := f.Value.([]interface{})
+		for _, target := range targets {
+			if equal(target, value) {
+				return true
+			}
+		}
+		return false
+	case Matches:
+		pattern, _ := f.Value.(string)
+		str, isString := value.(string)
+		regex, err := compileRegex(pattern)
+		return isString && err == nil && regex.MatchString(str)
+	case Prefix:
+		prefix, _ := f.Value.(string)
+		str, isString := value.(string)
+		return isString && strings.HasPrefix(str, prefix)
+	case LessThan, LessOrEqual, GreaterThan, GreaterOrEqual:
+		target, targetOk := number(f.Value)
+		actual, actualOk := number(value)
+		if !targetOk || !actualOk {
+			return false
+		}
+		switch f.Operator {
+		case LessThan:
+			return actual < target
+		case LessOrEqual:
+			return actual <= target
+		case GreaterThan:
+			return actual > target
+		default:
+			return actual >= target
+		}
+	default:
+		return false
+	}
+}
+
+// resolve follows parts from data, and returns the value found at the end. It
+// returns false if any part of the path doesn't exist.
+func resolve(parts []string, data interface{}) (interface{}, bool) {
+	if len(parts) == 0 {
+		return data, true
+	}
+
+	switch data := data.(type) {
+	case map[string]interface{}:
+		next, ok := data[parts[0]]
+		if !ok {
+			return nil, false
+		}
+		return resolve(parts[1:], next)
+	case []interface{}:
+		ix, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, false
+		}
+		if ix < 0 || ix >= len(data) {
+			return nil, false
+		}
+		return resolve(parts[1:], data[ix])
+	default:
+		return nil, false
+	}
+}
+
+// equal compares two JSON values. Numbers are compared by value, regardless of
+// the Go type they were decoded or declared as.
+func equal(target, data interface{}) bool {
+	if targetNumber, ok := number(target); ok {
+		dataNumber, ok := number(data)
+		return ok && targetNumber == dataNumber
+	}
+
+	// Objects and lists are compared recursively, so any numbers within them
+	// are compared by value whether they were decoded as float64 or as
+	// json.Number.
+	switch target := target.(type) {
+	case map[string]interface{}:
+		data, ok := data.(map[string]interface{})
+		if !ok || len(target) != len(data) {
+			return false
+		}
+		for key, value := range target {
+			other, ok := data[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		data, ok := data.([]interface{})
+		if !ok || len(target) != len(data) {
+			return false
+		}
+		for ix := range target {
+			if !equal(target[ix], data[ix]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(target, data)
+}
+
+// number converts any numeric Go value into a float64.
+func number(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case json.Number:
+		f, err := value.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
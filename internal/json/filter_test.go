@@ -0,0 +1,98 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package json
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	data := map[string]interface{}{
+		"address": "random_pet.one",
+		"type":    "apply_progress",
+		"count":   float64(3),
+		"tags": map[string]interface{}{
+			"env": "prod",
+		},
+		"list":    []interface{}{"one", "two"},
+		"deleted": nil,
+		"sizes": []interface{}{
+			map[string]interface{}{"size": json.Number("1")},
+		},
+	}
+
+	tcs := map[string]struct {
+		filter   string
+		expected bool
+	}{
+		"equal":                  {`{"path": ["type"], "value": "apply_progress"}`, true},
+		"equal_explicit":         {`{"path": ["type"], "operator": "eq", "value": "apply_complete"}`, false},
+		"equal_object":           {`{"path": ["tags"], "value": {"env": "prod"}}`, true},
+		"equal_list":             {`{"path": ["list"], "value": ["one", "two"]}`, true},
+		"equal_nested_numbers":   {`{"path": ["sizes"], "value": [{"size": 1.0}]}`, true},
+		"equal_absent":           {`{"path": ["missing"], "value": null}`, false},
+		"equal_null":             {`{"path": ["deleted"], "value": null}`, false},
+		"equal_null_no_value":    {`{"path": ["deleted"]}`, false},
+		"in_null":                {`{"path": ["deleted"], "operator": "in", "value": [null]}`, false},
+		"exists_null":            {`{"path": ["deleted"], "operator": "exists"}`, true},
+		"not_equal":              {`{"path": ["type"], "operator": "ne", "value": "apply_complete"}`, true},
+		"not_equal_absent":       {`{"path": ["missing"], "operator": "ne", "value": "apply_complete"}`, false},
+		"exists":                 {`{"path": ["tags", "env"], "operator": "exists"}`, true},
+		"exists_missing":         {`{"path": ["tags", "region"], "operator": "exists"}`, false},
+		"absent":                 {`{"path": ["tags", "region"], "operator": "absent"}`, true},
+		"in":                     {`{"path": ["type"], "operator": "in", "value": ["apply_start", "apply_progress"]}`, true},
+		"in_missing":             {`{"path": ["type"], "operator": "in", "value": ["apply_start"]}`, false},
+		"regex":                  {`{"path": ["address"], "operator": "regex", "value": "^random_[a-z]+\\.one$"}`, true},
+		"regex_not_string":       {`{"path": ["count"], "operator": "regex", "value": "3"}`, false},
+		"prefix":                 {`{"path": ["address"], "operator": "prefix", "value": "random_"}`, true},
+		"prefix_missing":         {`{"path": ["address"], "operator": "prefix", "value": "aws_"}`, false},
+		"less_than":              {`{"path": ["count"], "operator": "lt", "value": 4}`, true},
+		"less_or_equal":          {`{"path": ["count"], "operator": "le", "value": 3}`, true},
+		"greater_than":           {`{"path": ["count"], "operator": "gt", "value": 3}`, false},
+		"greater_or_equal":       {`{"path": ["count"], "operator": "ge", "value": 3}`, true},
+		"greater_than_string":    {`{"path": ["type"], "operator": "gt", "value": 3}`, false},
+		"list_index":             {`{"path": ["list", "1"], "value": "two"}`, true},
+		"and":                    {`{"and": [{"path": ["type"], "value": "apply_progress"}, {"path": ["address"], "operator": "prefix", "value": "random_"}]}`, true},
+		"and_mismatch":           {`{"and": [{"path": ["type"], "value": "apply_progress"}, {"path": ["address"], "operator": "prefix", "value": "aws_"}]}`, false},
+		"or":                     {`{"or": [{"path": ["type"], "value": "apply_start"}, {"path": ["address"], "operator": "prefix", "value": "random_"}]}`, true},
+		"or_mismatch":            {`{"or": [{"path": ["type"], "value": "apply_start"}, {"path": ["address"], "operator": "prefix", "value": "aws_"}]}`, false},
+		"nested_path":            {`{"path": ["tags"], "or": [{"path": ["env"], "value": "dev"}, {"path": ["env"], "value": "prod"}]}`, true},
+		"nested_path_missing":    {`{"path": ["missing"], "or": [{"operator": "absent", "path": ["env"]}]}`, false},
+		"and_or":                 {`{"and": [{"path": ["count"], "operator": "gt", "value": 1}], "or": [{"path": ["type"], "value": "apply_progress"}]}`, true},
+		"and_or_mismatch":        {`{"and": [{"path": ["count"], "operator": "gt", "value": 5}], "or": [{"path": ["type"], "value": "apply_progress"}]}`, false},
+		"equal_number_types":     {`{"path": ["count"], "value": 3.0}`, true},
+		"not_equal_number_types": {`{"path": ["count"], "operator": "ne", "value": 3}`, false},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var filter Filter
+			if err := json.Unmarshal([]byte(tc.filter), &filter); err != nil {
+				t.Fatalf("could not unmarshal filter: %v", err)
+			}
+
+			if actual := filter.matches(data); actual != tc.expected {
+				t.Fatalf("expected %t, but found %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFilter_Invalid(t *testing.T) {
+	tcs := map[string]string{
+		"unknown_operator": `{"path": ["type"], "operator": "like", "value": "apply"}`,
+		"in_not_list":      `{"path": ["type"], "operator": "in", "value": "apply"}`,
+		"regex_invalid":    `{"path": ["type"], "operator": "regex", "value": "("}`,
+		"prefix_number":    `{"path": ["type"], "operator": "prefix", "value": 1}`,
+		"lt_string":        `{"path": ["type"], "operator": "lt", "value": "one"}`,
+	}
+	for name, filter := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var f Filter
+			if err := json.Unmarshal([]byte(filter), &f); err == nil {
+				t.Fatalf("expected an error, but unmarshalling succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// builtinPatterns are the patterns that can be referenced by name alone,
+// without specifying a regular expression.
+//
+// There is no builtin for random pet names, as they are just words joined by a
+// separator and can't be told apart from other values such as `us-east-1`
+// without knowing the prefix, separator and length of the random_pet resource.
+var builtinPatterns = map[string]string{
+	"uuid":   `\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`,
+	"hex_id": `\b[0-9a-f]{16,}\b`,
+}
+
+// Pattern describes a kind of generated value, eg. a UUID, that should be
+// rewritten into a stable token by a Normalizer.
+//
+// If Regex is empty, Name must be one of the builtin patterns: uuid or hex_id.
+type Pattern struct {
+	Name  string `json:"name"`
+	Regex string `json:"pattern"`
+}
+
+func (p *Pattern) UnmarshalJSON(data []byte) error {
+	type pattern Pattern
+	if err := json.Unmarshal(data, (*pattern)(p)); err != nil {
+		return err
+	}
+	_, err := p.compile()
+	return err
+}
+
+func (p Pattern) compile() (*regexp.Regexp, error) {
+	if len(p.Name) == 0 {
+		return nil, fmt.Errorf("normalization patterns must have a name")
+	}
+
+	pattern := p.Regex
+	if len(pattern) == 0 {
+		builtin, ok := builtinPatterns[p.Name]
+		if !ok {
+			return nil, fmt.Errorf("unrecognized builtin pattern %s, specify a regular expression in pattern instead", p.Name)
+		}
+		pattern = builtin
+	}
+
+	regex, err := compileRegex(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %s for pattern %s: %v", pattern, p.Name, err)
+	}
+	return regex, nil
+}
+
+// Normalizer rewrites every value matching one of its patterns into a
+// sequential token such as `<uuid-1>`.
+//
+// The same value is always rewritten into the same token by a Normalizer, so
+// references between values are preserved even though the values themselves
+// change on every execution. A single Normalizer should be used for all the
+// files produced by a test, and the files should be normalized in a
+// consistent order so the tokens are numbered consistently between runs.
+type Normalizer struct {
+	patterns []Pattern
+	regexes  []*regexp.Regexp
+
+	// tokens maps the name of each pattern to the tokens already assigned to
+	// the values it matched.
+	tokens map[string]map[string]string
+}
+
+// NewNormalizer returns a Normalizer for the given patterns. Patterns are
+// applied in order, so a value matched by an earlier pattern is not
+// considered by the later ones.
+func NewNormalizer(patterns []Pattern) (*Normalizer, error) {
+	normalizer := &Normalizer{
+		patterns: patterns,
+		tokens:   make(map[string]map[string]string),
+	}
+	for _, pattern := range patterns {
+		regex, err := pattern.compile()
+		if err != nil {
+			return nil, err
+		}
+		normalizer.regexes = append(normalizer.regexes, regex)
+	}
+	return normalizer, nil
+}
+
+// String returns value with every substring matching one of the patterns
+// rewritten into its token.
+func (n *Normalizer) String(value string) string {
+	return n.replace(value, n.token)
+}
+
+// Json returns a copy of data with every string value normalized by String.
+// The keys of JSON objects are not normalized, and are visited in sorted
+// order so tokens are assigned deterministically.
+func (n *Normalizer) Json(data interface{}) interface{} {
+	return n.json(data, n.String)
+}
+
+// Erase returns a copy of data with every value matching one of the patterns
+// rewritten into the name of the pattern, eg. `<uuid>`, without assigning any
+// tokens. This makes values comparable regardless of the generated values they
+// contain, before the tokens are assigned.
+func (n *Normalizer) Erase(data interface{}) interface{} {
+	return n.json(data, func(value string) string {
+		return n.replace(value, func(name, _ string) string {
+			return fmt.Sprintf("<%s>", name)
+		})
+	})
+}
+
+func (n *Normalizer) replace(value string, token func(name, match string) string) string {
+	for ix, regex := range n.regexes {
+		name := n.patterns[ix].Name
+		value = regex.ReplaceAllStringFunc(value, func(match string) string {
+			return token(name, match)
+		})
+	}
+	return value
+}
+
+func (n *Normalizer) json(data interface{}, normalize func(string) string) interface{} {
+	switch node := data.(type) {
+	case string:
+		return normalize(node)
+	case map[string]interface{}:
+		var keys []string
+		for key := range node {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		ret := make(map[string]interface{}, len(node))
+		for _, key := range keys {
+			ret[key] = n.json(node[key], normalize)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, 0, len(node))
+		for _, item := range node {
+			ret = append(ret, n.json(item, normalize))
+		}
+		return ret
+	default:
+		return data
+	}
+}
+
+func (n *Normalizer) token(name, value string) string {
+	tokens, ok := n.tokens[name]
+	if !ok {
+		tokens = make(map[string]string)
+		n.tokens[name] = tokens
+	}
+
+	if token, ok := tokens[value]; ok {
+		return token
+	}
+
+	token := fmt.Sprintf("<%s-%d>", name, len(tokens)+1)
+	tokens[value] = token
+	return token
+}
@@ -0,0 +1,99 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package json
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNormalizer(t *testing.T) {
+	normalizer, err := NewNormalizer([]Pattern{
+		{Name: "uuid"},
+		{Name: "hex_id"},
+		{Name: "pet", Regex: `\b(?:happy|sad)-(?:dog|cat)\b`},
+	})
+	if err != nil {
+		t.Fatalf("NewNormalizer failed unexpectedly: %v", err)
+	}
+
+	actual := normalizer.Json(map[string]interface{}{
+		"b_id": "3f0c1d9e-5d2a-4b57-9a8e-2f1b7c6d5e4a",
+		"a_id": "8a7b6c5d-1234-4abc-9def-0123456789ab",
+		"refs": []interface{}{
+			"3f0c1d9e-5d2a-4b57-9a8e-2f1b7c6d5e4a",
+			"arn:bucket/8a7b6c5d-1234-4abc-9def-0123456789ab/happy-dog",
+		},
+		"hash":  "0123456789abcdef0123",
+		"short": "abcdef",
+		"name":  "sad-cat",
+		"count": float64(1),
+	})
+
+	expected := map[string]interface{}{
+		"b_id": "<uuid-2>",
+		"a_id": "<uuid-1>",
+		"refs": []interface{}{
+			"<uuid-2>",
+			"arn:bucket/<uuid-1>/<pet-2>",
+		},
+		"hash":  "<hex_id-1>",
+		"short": "abcdef",
+		"name":  "<pet-1>",
+		"count": float64(1),
+	}
+
+	if diff := cmp.Diff(expected, actual); len(diff) > 0 {
+		t.Errorf("expected no diff but found \n%s", diff)
+	}
+
+	// Tokens should be shared with any later values, including raw strings.
+	if actual := normalizer.String("created sad-cat with id 3f0c1d9e-5d2a-4b57-9a8e-2f1b7c6d5e4a"); actual != "created <pet-1> with id <uuid-2>" {
+		t.Errorf("unexpected normalized string %q", actual)
+	}
+}
+
+func TestNormalizer_Erase(t *testing.T) {
+	normalizer, err := NewNormalizer([]Pattern{{Name: "uuid"}})
+	if err != nil {
+		t.Fatalf("NewNormalizer failed unexpectedly: %v", err)
+	}
+
+	actual := normalizer.Erase(map[string]interface{}{
+		"id":   "3f0c1d9e-5d2a-4b57-9a8e-2f1b7c6d5e4a",
+		"refs": []interface{}{"arn:bucket/8a7b6c5d-1234-4abc-9def-0123456789ab"},
+	})
+
+	expected := map[string]interface{}{
+		"id":   "<uuid>",
+		"refs": []interface{}{"arn:bucket/<uuid>"},
+	}
+	if diff := cmp.Diff(expected, actual); len(diff) > 0 {
+		t.Errorf("expected no diff but found \n%s", diff)
+	}
+
+	// Erasing values shouldn't assign any tokens.
+	if actual := normalizer.String("8a7b6c5d-1234-4abc-9def-0123456789ab"); actual != "<uuid-1>" {
+		t.Errorf("unexpected normalized string %q", actual)
+	}
+}
+
+func TestPattern_Invalid(t *testing.T) {
+	tcs := map[string]string{
+		"missing_name":    `{"pattern": "[a-z]+"}`,
+		"unknown_builtin": `{"name": "pet"}`,
+		"invalid_regex":   `{"name": "pet", "pattern": "[a-z"}`,
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var pattern Pattern
+			if err := json.Unmarshal([]byte(tc), &pattern); err == nil {
+				t.Errorf("expected an error for %s", tc)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Sort mutates the input data by sorting the JSON arrays found at all the
+// required fields, so that arrays which are really unordered sets compare
+// equal regardless of the order their items were produced in.
+//
+// Fields are selected in exactly the same way as Strip, and must select the
+// arrays themselves. Any selected values that are not arrays are left alone.
+//
+// If key is empty, the items are ordered by their entire contents. Otherwise,
+// key is a path followed from each item, and the items are ordered by the
+// value found at the end of it, with any items missing the key first. Items
+// with equal keys are then ordered by their entire contents, so the result
+// doesn't depend on the original order.
+//
+// Numbers are ordered numerically and strings lexically, while any other
+// values are ordered by their JSON encodings.
+func Sort(fields [][]Step, key []string, data interface{}) (interface{}, error) {
+	return SortBy(fields, key, nil, data)
+}
+
+// SortBy sorts the JSON arrays found at all the required fields in the same
+// way as Sort, except that the items are compared by the values returned by
+// view. The items themselves are unchanged. If view is nil, the items are
+// compared directly.
+//
+// Items that compare equal keep their original order.
+func SortBy(fields [][]Step, key []string, view func(interface{}) interface{}, data interface{}) (interface{}, error) {
+	return stripper{
+		replace: func(value interface{}) (interface{}, error) {
+			items, ok := value.([]interface{})
+			if !ok {
+				return value, nil
+			}
+			return sortItems(items, key, view)
+		},
+	}.apply(fields, data)
+}
+
+func sortItems(items []interface{}, key []string, view func(interface{}) interface{}) ([]interface{}, error) {
+	type sortable struct {
+		key    interface{}
+		hasKey bool
+		item   interface{}
+
+		// compared is the item as seen through the view, which is used in
+		// place of the item when comparing items.
+		compared interface{}
+	}
+
+	var entries []sortable
+	for _, item := range items {
+		entry := sortable{item: item, compared: item}
+		if view != nil {
+			entry.compared = view(item)
+		}
+		entry.key, entry.hasKey = resolve(key, entry.compared)
+		entries = append(entries, entry)
+	}
+
+	// The less function can't return an error, so keep the first one and
+	// report it once the sort is finished.
+	var err error
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].hasKey != entries[j].hasKey {
+			return !entries[i].hasKey
+		}
+		order, compareErr := compare(entries[i].key, entries[j].key)
+		if compareErr == nil && order == 0 {
+			order, compareErr = compare(entries[i].compared, entries[j].compared)
+		}
+		if compareErr != nil && err == nil {
+			err = compareErr
+		}
+		return order < 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]interface{}, 0, len(items))
+	for _, entry := range entries {
+		sorted = append(sorted, entry.item)
+	}
+	return sorted, nil
+}
+
+// compare orders numbers numerically and strings lexically. Any other values,
+// including values of different types, are ordered by their JSON encodings.
+func compare(left, right interface{}) (int, error) {
+	if left, ok := number(left); ok {
+		if right, ok := number(right); ok {
+			switch {
+			case left < right:
+				return -1, nil
+			case left > right:
+				return 1, nil
+			default:
+				return 0, nil
+			}
+		}
+	}
+
+	if left, ok := left.(string); ok {
+		if right, ok := right.(string); ok {
+			return strings.Compare(left, right), nil
+		}
+	}
+
+	leftJson, err := encode(left)
+	if err != nil {
+		return 0, err
+	}
+	rightJson, err := encode(right)
+	if err != nil {
+		return 0, err
+	}
+	return bytes.Compare(leftJson, rightJson), nil
+}
+
+// encode returns the JSON encoding of value. The keys of JSON objects are
+// always encoded in sorted order, so equal values have equal encodings.
+func encode(value interface{}) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode value to compare it: %v", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,130 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package json
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSortJson(t *testing.T) {
+	tcs := map[string]struct {
+		input    string
+		field    string
+		key      []string
+		expected string
+	}{
+		"by_contents": {
+			input:    `{"list": ["b", "a", "c"]}`,
+			field:    "list",
+			expected: `{"list": ["a", "b", "c"]}`,
+		},
+		"numbers": {
+			input:    `{"list": [10, 2, 1]}`,
+			field:    "list",
+			expected: `{"list": [1, 2, 10]}`,
+		},
+		"objects": {
+			input:    `{"list": [{"b": 1}, {"a": 2}, {"a": 1}]}`,
+			field:    "list",
+			expected: `{"list": [{"a": 1}, {"a": 2}, {"b": 1}]}`,
+		},
+		"by_key": {
+			input:    `{"changes": [{"address": "b", "id": 1}, {"address": "a", "id": 2}, {"id": 3}]}`,
+			field:    "changes",
+			key:      []string{"address"},
+			expected: `{"changes": [{"id": 3}, {"address": "a", "id": 2}, {"address": "b", "id": 1}]}`,
+		},
+		"equal_keys": {
+			input:    `{"changes": [{"address": "a", "id": 2}, {"address": "a", "id": 1}]}`,
+			field:    "changes",
+			key:      []string{"address"},
+			expected: `{"changes": [{"address": "a", "id": 1}, {"address": "a", "id": 2}]}`,
+		},
+		"nested": {
+			input:    `{"resources": [{"tags": ["z", "y"]}, {"tags": ["b", "a"]}]}`,
+			field:    "resources.*.tags",
+			expected: `{"resources": [{"tags": ["y", "z"]}, {"tags": ["a", "b"]}]}`,
+		},
+		"recursive": {
+			input:    `{"a": {"ids": [2, 1]}, "b": [{"ids": [4, 3]}]}`,
+			field:    "**.ids",
+			expected: `{"a": {"ids": [1, 2]}, "b": [{"ids": [3, 4]}]}`,
+		},
+		"not_an_array": {
+			input:    `{"list": "b,a"}`,
+			field:    "list",
+			expected: `{"list": "b,a"}`,
+		},
+		"missing": {
+			input:    `{"list": ["b", "a"]}`,
+			field:    "other",
+			expected: `{"list": ["b", "a"]}`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var input, expected interface{}
+			if err := json.Unmarshal([]byte(tc.input), &input); err != nil {
+				t.Fatalf("failed to unmarshal input: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tc.expected), &expected); err != nil {
+				t.Fatalf("failed to unmarshal expected: %v", err)
+			}
+
+			actual, err := Sort([][]Step{Field(tc.field)}, tc.key, input)
+			if err != nil {
+				t.Fatalf("failed to sort: %v", err)
+			}
+
+			if diff := cmp.Diff(expected, actual); len(diff) > 0 {
+				t.Errorf("expected no diff but found \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	input := map[string]interface{}{
+		"list": []interface{}{"b-2", "a-3", "b-1", "a-1"},
+	}
+
+	// Only compare the letters, so items with the same letter keep their
+	// original order.
+	view := func(item interface{}) interface{} {
+		return strings.Split(item.(string), "-")[0]
+	}
+
+	actual, err := SortBy([][]Step{Field("list")}, nil, view, input)
+	if err != nil {
+		t.Fatalf("failed to sort: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"list": []interface{}{"a-3", "a-1", "b-2", "b-1"},
+	}
+	if diff := cmp.Diff(expected, actual); len(diff) > 0 {
+		t.Errorf("expected no diff but found \n%s", diff)
+	}
+}
+
+func TestSortBy_EncodeError(t *testing.T) {
+	input := map[string]interface{}{
+		"list": []interface{}{map[string]interface{}{}, map[string]interface{}{}},
+	}
+
+	// Values that can't be encoded as JSON can't be ordered, so sorting should
+	// fail rather than panic.
+	view := func(item interface{}) interface{} {
+		return map[string]interface{}{"value": func() {}}
+	}
+
+	if _, err := SortBy([][]Step{Field("list")}, nil, view, input); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
@@ -3,53 +3,203 @@
 
 package json
 
-import "strconv"
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// regexes caches the compiled regular expressions used by any Step or Filter,
+// as the same steps are applied to every key of large JSON objects.
+var regexes sync.Map
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if regex, ok := regexes.Load(pattern); ok {
+		return regex.(*regexp.Regexp), nil
+	}
+
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexes.Store(pattern, regex)
+	return regex, nil
+}
 
 // Step represents a step in the path to a field that should be stripped from
 // the input data.
+//
+// When traversing JSON objects, a Step can be a literal key, the `*` wildcard
+// matching every key, a glob pattern such as `*_id`, or a regular expression wrapped in forward slashes such as
+// `/^computed_/`. When traversing JSON arrays, a Step must be an integer index
+// or the `*` wildcard.
+//
+// A backslash escapes the following character, so keys that contain `*`, `?`,
+// `[`, or that start with a forward slash can still be matched literally, eg.
+// `kubernetes.io/\*` or `\/path/`.
+//
+// Glob patterns use the path.Match syntax, except that `*` and `?` also match
+// forward slashes, as the keys of JSON objects are not file paths.
 type Step struct {
-	Step   string
-	Filter []Filter
+	Step   string   `json:"step"`
+	Filter []Filter `json:"filter"`
 }
 
-// Filter represents a filter that should be validated before a Field is
-// stripped.
-type Filter struct {
-	Path  []string
-	Value interface{}
+// isPattern returns true if this step can match more than one key in a JSON
+// object.
+func (step Step) isPattern() bool {
+	return step.Step == wildcard || step.isRegex() || containsUnescaped(step.Step, "*?[")
 }
 
-func (step Step) applyFilter(data interface{}) bool {
-	for _, f := range step.Filter {
-		if !filter(f.Path, f.Value, data) {
-			return false
+func (step Step) isRegex() bool {
+	return len(step.Step) >= 2 && strings.HasPrefix(step.Step, "/") && strings.HasSuffix(step.Step, "/")
+}
+
+// matchesKey returns true if this step selects the given key of a JSON object.
+func (step Step) matchesKey(key string) (bool, error) {
+	switch {
+	case step.Step == wildcard:
+		return true, nil
+	case step.isRegex():
+		regex, err := step.regex()
+		if err != nil {
+			return false, err
 		}
+		return regex.MatchString(key), nil
+	case step.isPattern():
+		regex, err := step.glob()
+		if err != nil {
+			return false, err
+		}
+		return regex.MatchString(key), nil
+	default:
+		return step.key() == key, nil
 	}
-	return true
 }
 
-func filter(parts []string, target interface{}, data interface{}) bool {
-	if data == nil {
-		return false
+// key returns the literal key this step selects from a JSON object, with any
+// escaping backslashes removed. It is only meaningful if the step isn't a
+// pattern.
+func (step Step) key() string {
+	if !strings.Contains(step.Step, `\`) {
+		return step.Step
 	}
 
-	if len(parts) == 0 {
-		return target == data
+	var key strings.Builder
+	for ix := 0; ix < len(step.Step); ix++ {
+		if step.Step[ix] == '\\' && ix+1 < len(step.Step) {
+			ix++
+		}
+		key.WriteByte(step.Step[ix])
 	}
+	return key.String()
+}
 
-	switch data := data.(type) {
-	case map[string]interface{}:
-		return filter(parts[1:], target, data[parts[0]])
-	case []interface{}:
-		ix, err := strconv.Atoi(parts[0])
-		if err != nil {
-			return false
+// containsUnescaped returns true if value contains any of the chars that are
+// not escaped by a backslash.
+func containsUnescaped(value string, chars string) bool {
+	for ix := 0; ix < len(value); ix++ {
+		if value[ix] == '\\' {
+			ix++
+			continue
+		}
+		if strings.IndexByte(chars, value[ix]) >= 0 {
+			return true
 		}
-		if ix < 0 || ix >= len(data) {
+	}
+	return false
+}
+
+func (step Step) regex() (*regexp.Regexp, error) {
+	regex, err := compileRegex(step.Step[1 : len(step.Step)-1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %s: %v", step.Step, err)
+	}
+	return regex, nil
+}
+
+// glob returns the glob pattern of this step as an anchored regular
+// expression.
+func (step Step) glob() (*regexp.Regexp, error) {
+	pattern, err := globToRegex(step.Step)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %s: %v", step.Step, err)
+	}
+	regex, err := compileRegex(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %s: %v", step.Step, err)
+	}
+	return regex, nil
+}
+
+// globToRegex translates a glob pattern into an anchored regular expression.
+// Every literal character is written as a hex escape, so it can't be mistaken
+// for part of the regular expression syntax.
+func globToRegex(glob string) (string, error) {
+	var regex strings.Builder
+	regex.WriteString(`^(?s:`)
+
+	inClass := false
+	classStart := 0
+	for ix := 0; ix < len(glob); {
+		char, size := utf8.DecodeRuneInString(glob[ix:])
+		ix += size
+
+		if char == '\\' {
+			if ix >= len(glob) {
+				return "", errors.New("trailing backslash")
+			}
+			char, size = utf8.DecodeRuneInString(glob[ix:])
+			ix += size
+			fmt.Fprintf(&regex, `\x{%x}`, char)
+			continue
+		}
+
+		if inClass {
+			switch {
+			case char == '^' && regex.Len() == classStart:
+				regex.WriteRune('^')
+				classStart = regex.Len()
+			case char == ']' && regex.Len() > classStart:
+				regex.WriteRune(']')
+				inClass = false
+			case char == '-' && regex.Len() > classStart:
+				regex.WriteRune('-')
+			default:
+				fmt.Fprintf(&regex, `\x{%x}`, char)
+			}
+			continue
+		}
+
+		switch char {
+		case '*':
+			regex.WriteString(`.*`)
+		case '?':
+			regex.WriteString(`.`)
+		case '[':
+			regex.WriteRune('[')
+			inClass = true
+			classStart = regex.Len()
+		default:
+			fmt.Fprintf(&regex, `\x{%x}`, char)
+		}
+	}
+
+	if inClass {
+		return "", errors.New("unterminated character class")
+	}
+	regex.WriteString(`)$`)
+	return regex.String(), nil
+}
+
+func (step Step) applyFilter(data interface{}) bool {
+	for _, f := range step.Filter {
+		if !f.matches(data) {
 			return false
 		}
-		return filter(parts[1:], target, data[ix])
-	default:
-		return false
 	}
+	return true
 }
@@ -4,31 +4,209 @@
 package json
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
 )
 
 const (
-	wildcard = "*"
+	wildcard          = "*"
+	recursiveWildcard = "**"
 )
 
+// MissingPaths controls what happens when a field references a key or index
+// that doesn't exist in the data.
+type MissingPaths string
+
+const (
+	// SkipMissingPaths ignores any keys or indexes that don't exist. This is
+	// the default if MissingPaths is empty.
+	SkipMissingPaths MissingPaths = "skip"
+
+	// ErrorOnMissingPaths returns an error for any keys or indexes that don't
+	// exist, except for those beneath a `**` step.
+	ErrorOnMissingPaths MissingPaths = "error"
+)
+
+func (missing *MissingPaths) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch MissingPaths(value) {
+	case "", SkipMissingPaths, ErrorOnMissingPaths:
+		*missing = MissingPaths(value)
+		return nil
+	default:
+		return fmt.Errorf("unrecognized value %q for missing paths, expected %q or %q", value, SkipMissingPaths, ErrorOnMissingPaths)
+	}
+}
+
+// Field converts a dotted path, eg. `resource_changes.*.change.after.id`, into
+// the equivalent list of steps.
+//
+// A step wrapped in forward slashes, eg. `/^computed_/`, is a regular
+// expression and may contain dots. Outside of regular expressions, a backslash
+// escapes the following character, so `kubernetes\.io/name` is a single step.
 func Field(path string) []Step {
 	var field []Step
-	for _, step := range strings.Split(path, ".") {
-		field = append(field, Step{Step: step})
+	var current strings.Builder
+	regex := false
+	for ix := 0; ix < len(path); ix++ {
+		char := path[ix]
+		switch {
+		case char == '/' && current.Len() == 0 && !regex:
+			regex = true
+		case char == '/' && regex && path[ix-1] != '\\':
+			regex = false
+		case char == '\\' && !regex && ix+1 < len(path):
+			// Keep the escaped character, and the backslash so the step
+			// still knows it was escaped.
+			current.WriteByte(char)
+			ix++
+			char = path[ix]
+		case char == '.' && !regex:
+			field = append(field, Step{Step: current.String()})
+			current.Reset()
+			continue
+		}
+		current.WriteByte(char)
 	}
-	return field
+	return append(field, Step{Step: current.String()})
 }
 
 // Strip mutates the input data by removing all the required fields.
 //
 // Check out the strip_test.go test cases for examples of the accepted format
 // for each field.
+//
+// Any keys or indexes in the fields that don't exist in the data are skipped.
 func Strip(fields [][]Step, data interface{}) (interface{}, error) {
+	data, _, err := StripAndCount(fields, SkipMissingPaths, data)
+	return data, err
+}
+
+// StripAndCount is the same as Strip, except it also returns the number of
+// values that were removed by each field. A field that removed nothing has
+// probably been misspelled, or the JSON format has changed since it was
+// written.
+//
+// Any keys or indexes in the fields that don't exist in the data are handled
+// according to missing.
+func StripAndCount(fields [][]Step, missing MissingPaths, data interface{}) (interface{}, []int, error) {
+	counts := make([]int, len(fields))
+	s := stripper{missing: missing}
+	for ix, field := range fields {
+		s.matched = func() {
+			counts[ix]++
+		}
+
+		var err error
+		if data, err = s.strip(field, data); err != nil {
+			return nil, nil, err
+		}
+	}
+	return data, counts, nil
+}
+
+// Mask mutates the input data by replacing the values of all the required
+// fields with placeholder. Unlike Strip, the masked fields are still present
+// in the output so the presence of a field can still be compared even when
+// its value can't.
+//
+// The type of each masked value is still compared as well. Strings are
+// replaced with placeholder, while the JSON type of any other values is added
+// into the placeholder, eg. `<masked:number>` or `<masked:object>`, and null
+// values are left alone.
+//
+// Fields are selected in exactly the same way as Strip, and any keys or
+// indexes in the fields that don't exist in the data are handled according to
+// missing.
+func Mask(fields [][]Step, placeholder string, missing MissingPaths, data interface{}) (interface{}, error) {
+	return stripper{
+		replace: func(value interface{}) (interface{}, error) {
+			return mask(placeholder, value), nil
+		},
+		missing: missing,
+	}.apply(fields, data)
+}
+
+// mask returns the value that replaces value when it is masked by
+// placeholder.
+func mask(placeholder string, value interface{}) interface{} {
+	kind := jsonType(value)
+	switch kind {
+	case "null":
+		return nil
+	case "string", "unknown":
+		return placeholder
+	}
+
+	if strings.HasSuffix(placeholder, ">") {
+		return fmt.Sprintf("%s:%s>", strings.TrimSuffix(placeholder, ">"), kind)
+	}
+	return fmt.Sprintf("%s:%s", placeholder, kind)
+}
+
+// jsonType returns the name of the JSON type of value, or unknown if value
+// couldn't have been decoded from JSON.
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+
+	if _, ok := number(value); ok {
+		return "number"
+	}
+	return "unknown"
+}
+
+// stripper walks the JSON data along the steps of a field, and removes or
+// replaces the values it finds at the end.
+type stripper struct {
+	// replace returns the value that a selected value should be replaced
+	// with. If replace is nil, selected values are removed instead.
+	replace func(value interface{}) (interface{}, error)
+
+	// matched is called every time a value is removed or replaced, if it is
+	// not nil.
+	matched func()
+
+	// missing decides whether keys and indexes that don't exist are skipped,
+	// or reported as errors.
+	missing MissingPaths
+}
+
+func (s stripper) match() {
+	if s.matched != nil {
+		s.matched()
+	}
+}
+
+// absent is called whenever a step references a value that doesn't exist. It
+// returns nil if missing paths should be skipped, or an error otherwise.
+func (s stripper) absent(format string, args ...interface{}) error {
+	if s.missing == ErrorOnMissingPaths {
+		return fmt.Errorf(format, args...)
+	}
+	return nil
+}
+
+func (s stripper) apply(fields [][]Step, data interface{}) (interface{}, error) {
 	for _, field := range fields {
 		var err error
-		data, err = strip(field, data)
+		data, err = s.strip(field, data)
 		if err != nil {
 			return nil, err
 		}
@@ -36,124 +214,264 @@ func Strip(fields [][]Step, data interface{}) (interface{}, error) {
 	return data, nil
 }
 
-func strip(steps []Step, current interface{}) (interface{}, error) {
+func (s stripper) strip(steps []Step, current interface{}) (interface{}, error) {
 	if current == nil {
-		return nil, nil
+		return nil, s.absent("cannot find %s within a null value", steps[0].Step)
+	}
+
+	if steps[0].Step == recursiveWildcard {
+		return s.stripRecursive(steps, current)
 	}
 
 	if len(steps) == 1 {
-		return stripLeaf(steps[0], current)
+		return s.stripLeaf(steps[0], current)
+	}
+
+	return s.stripNode(steps, current)
+}
+
+// stripRecursive handles the `**` step, which matches any number of levels
+// (including none) in the JSON data. The remaining steps are applied to the
+// current node, and then to every JSON object and array nested beneath it at
+// any depth.
+//
+// The remaining steps are only applied to JSON arrays if the next step is the
+// `*` wildcard or an integer index within the array, so `**.id` removes every
+// `id` key without failing on the arrays in between.
+//
+// Any filter on the `**` step is checked against each node before the
+// remaining steps are applied to it. A trailing `**` step behaves in the same
+// way as a trailing `*` step.
+//
+// Missing paths are always skipped beneath the `**` step, as the remaining
+// steps are not expected to exist within every node.
+func (s stripper) stripRecursive(parts []Step, current interface{}) (interface{}, error) {
+	s.missing = SkipMissingPaths
+
+	descent, rest := parts[0], parts[1:]
+	if len(rest) == 0 {
+		return s.strip([]Step{{Step: wildcard, Filter: descent.Filter}}, current)
+	}
+
+	var err error
+	switch node := current.(type) {
+	case map[string]interface{}:
+		if descent.applyFilter(node) {
+			if current, err = s.strip(rest, node); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		if descent.applyFilter(node) && selectsFromSlice(rest[0], node) {
+			if current, err = s.strip(rest, node); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		// Primitive values can't contain any more fields.
+		return current, nil
+	}
+
+	switch node := current.(type) {
+	case map[string]interface{}:
+		for key, value := range node {
+			if node[key], err = s.stripRecursive(parts, value); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for ix, item := range node {
+			if node[ix], err = s.stripRecursive(parts, item); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return current, nil
+}
+
+// selectsFromSlice returns true if step is the `*` wildcard, or an integer
+// index within current.
+func selectsFromSlice(step Step, current []interface{}) bool {
+	if step.Step == wildcard {
+		return true
+	}
+	_, ok, err := index(step, current)
+	return err == nil && ok
+}
+
+// index converts step into an index within current. Negative indexes count
+// backwards from the end of current, so -1 is the last item.
+//
+// It returns false if the index is outside current, and an error if the step
+// isn't an integer at all.
+func index(step Step, current []interface{}) (int, bool, error) {
+	ix, err := strconv.Atoi(step.Step)
+	if err != nil {
+		return 0, false, fmt.Errorf("must specify an integer when referencing json arrays, instead specified %s", step.Step)
 	}
 
-	return stripNode(steps, current)
+	if ix < 0 {
+		ix += len(current)
+	}
+	return ix, ix >= 0 && ix < len(current), nil
 }
 
-func stripLeaf(part Step, current interface{}) (interface{}, error) {
+func (s stripper) stripLeaf(part Step, current interface{}) (interface{}, error) {
 	switch leaf := current.(type) {
 	case map[string]interface{}:
-		return stripMapLeaf(part, leaf), nil
+		return s.stripMapLeaf(part, leaf)
 	case []interface{}:
-		return stripSliceLeaf(part, leaf)
+		return s.stripSliceLeaf(part, leaf)
 	default:
-		return nil, fmt.Errorf("unrecognised json type: %T", leaf)
+		return current, s.absent("cannot find %s within a %s value", part.Step, jsonType(leaf))
 	}
 }
 
-func stripMapLeaf(part Step, current map[string]interface{}) map[string]interface{} {
-	switch part.Step {
-	case wildcard:
+func (s stripper) stripMapLeaf(part Step, current map[string]interface{}) (map[string]interface{}, error) {
+	if part.isPattern() {
 		remaining := make(map[string]interface{})
 		for key, value := range current {
-			if !part.applyFilter(value) {
+			matches, err := part.matchesKey(key)
+			if err != nil {
+				return nil, err
+			}
+
+			if !matches || !part.applyFilter(value) {
 				remaining[key] = value
+				continue
 			}
-		}
-		return remaining
-	default:
-		if next, ok := current[part.Step]; ok {
-			if !part.applyFilter(next) {
-				return current
+
+			s.match()
+			if s.replace != nil {
+				replaced, err := s.replace(value)
+				if err != nil {
+					return nil, err
+				}
+				remaining[key] = replaced
 			}
 		}
-		delete(current, part.Step)
-		return current
+		return remaining, nil
 	}
+
+	next, ok := current[part.key()]
+	if !ok {
+		return current, s.absent("key %s does not exist", part.Step)
+	}
+
+	if !part.applyFilter(next) {
+		return current, nil
+	}
+
+	s.match()
+	if s.replace != nil {
+		replaced, err := s.replace(next)
+		if err != nil {
+			return nil, err
+		}
+		current[part.key()] = replaced
+		return current, nil
+	}
+	delete(current, part.key())
+	return current, nil
 }
 
-func stripSliceLeaf(part Step, current []interface{}) ([]interface{}, error) {
+func (s stripper) stripSliceLeaf(part Step, current []interface{}) ([]interface{}, error) {
 	switch part.Step {
 	case wildcard:
 		remaining := make([]interface{}, 0)
 		for _, item := range current {
 			if !part.applyFilter(item) {
 				remaining = append(remaining, item)
+				continue
+			}
+
+			s.match()
+			if s.replace != nil {
+				replaced, err := s.replace(item)
+				if err != nil {
+					return nil, err
+				}
+				remaining = append(remaining, replaced)
 			}
 		}
 		return remaining, nil
 	default:
-		ix, err := strconv.Atoi(part.Step)
+		ix, ok, err := index(part, current)
 		if err != nil {
-			return nil, fmt.Errorf("must specify an integer when referencing json arrays, instead specified %s", part)
+			return nil, err
 		}
 
-		if ix < 0 || ix >= len(current) {
-			return nil, fmt.Errorf("index %d out of bounds for array of length %d", ix, len(current))
+		if !ok {
+			return current, s.absent("index %s out of bounds for array of length %d", part.Step, len(current))
 		}
 
 		if !part.applyFilter(current[ix]) {
 			return current, nil
 		}
 
+		s.match()
+		if s.replace != nil {
+			replaced, err := s.replace(current[ix])
+			if err != nil {
+				return nil, err
+			}
+			current[ix] = replaced
+			return current, nil
+		}
 		return append(current[:ix], current[ix+1:]...), nil
 	}
 }
 
-func stripNode(parts []Step, current interface{}) (interface{}, error) {
+func (s stripper) stripNode(parts []Step, current interface{}) (interface{}, error) {
 	switch node := current.(type) {
 	case map[string]interface{}:
-		return stripMapNode(parts, node)
+		return s.stripMapNode(parts, node)
 	case []interface{}:
-		return stripSliceNode(parts, node)
+		return s.stripSliceNode(parts, node)
 	default:
-		return nil, fmt.Errorf("unrecognized json type: %T", node)
+		return current, s.absent("cannot find %s within a %s value", parts[0].Step, jsonType(node))
 	}
 }
 
-func stripMapNode(parts []Step, current map[string]interface{}) (map[string]interface{}, error) {
-	switch parts[0].Step {
-	case wildcard:
+func (s stripper) stripMapNode(parts []Step, current map[string]interface{}) (map[string]interface{}, error) {
+	if parts[0].isPattern() {
 		ret := map[string]interface{}{}
 		for key, value := range current {
-			if !parts[0].applyFilter(value) {
+			matches, err := parts[0].matchesKey(key)
+			if err != nil {
+				return nil, err
+			}
+
+			if !matches || !parts[0].applyFilter(value) {
 				ret[key] = value
 				continue
 			}
 
-			var err error
-			if ret[key], err = strip(parts[1:], value); err != nil {
+			if ret[key], err = s.strip(parts[1:], value); err != nil {
 				return nil, err
 			}
 		}
 		return ret, nil
-	default:
-		if _, ok := current[parts[0].Step]; !ok {
-			// If the JSON object doesn't have this path, just skip it.
-			return current, nil
-		}
+	}
 
-		if !parts[0].applyFilter(current[parts[0].Step]) {
-			return current, nil
-		}
+	if _, ok := current[parts[0].key()]; !ok {
+		// If the JSON object doesn't have this path, just skip it unless
+		// we've been told not to.
+		return current, s.absent("key %s does not exist", parts[0].Step)
+	}
 
-		var err error
-		if current[parts[0].Step], err = strip(parts[1:], current[parts[0].Step]); err != nil {
-			return nil, err
-		}
+	if !parts[0].applyFilter(current[parts[0].key()]) {
 		return current, nil
 	}
+
+	var err error
+	if current[parts[0].key()], err = s.strip(parts[1:], current[parts[0].key()]); err != nil {
+		return nil, err
+	}
+	return current, nil
 }
 
-func stripSliceNode(parts []Step, current []interface{}) ([]interface{}, error) {
+func (s stripper) stripSliceNode(parts []Step, current []interface{}) ([]interface{}, error) {
 	switch parts[0].Step {
 	case wildcard:
 		ret := make([]interface{}, 0)
@@ -163,7 +481,7 @@ func stripSliceNode(parts []Step, current []interface{}) ([]interface{}, error)
 				continue
 			}
 
-			stripped, err := strip(parts[1:], item)
+			stripped, err := s.strip(parts[1:], item)
 			if err != nil {
 				return nil, err
 			}
@@ -171,20 +489,20 @@ func stripSliceNode(parts []Step, current []interface{}) ([]interface{}, error)
 		}
 		return ret, nil
 	default:
-		ix, err := strconv.Atoi(parts[0].Step)
+		ix, ok, err := index(parts[0], current)
 		if err != nil {
-			return nil, fmt.Errorf("must specify an integer when referencing json arrays, instead specified %s", parts[0])
+			return nil, err
 		}
 
-		if ix < 0 || ix >= len(current) {
-			return nil, fmt.Errorf("index %d out of bounds for array of length %d", ix, len(current))
+		if !ok {
+			return current, s.absent("index %s out of bounds for array of length %d", parts[0].Step, len(current))
 		}
 
 		if !parts[0].applyFilter(current[ix]) {
 			return current, nil
 		}
 
-		if current[ix], err = strip(parts[1:], current[ix]); err != nil {
+		if current[ix], err = s.strip(parts[1:], current[ix]); err != nil {
 			return nil, err
 		}
 		return current, nil
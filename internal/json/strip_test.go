@@ -6,7 +6,10 @@ package json
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestStripJson(t *testing.T) {
@@ -483,6 +486,235 @@ func TestStripJson(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: map[string]interface{}{
+				"resource_id": "one",
+				"region_id":   "two",
+				"name":        "three",
+				"nested": map[string]interface{}{
+					"subnet_id": "four",
+					"name":      "five",
+				},
+			},
+			expected: map[string]interface{}{
+				"name": "three",
+				"nested": map[string]interface{}{
+					"name": "five",
+				},
+			},
+			fields: [][]Step{
+				Field("*_id"),
+				Field("nested.*_id"),
+			},
+		},
+		{
+			input: map[string]interface{}{
+				"tags": map[string]interface{}{
+					"name_id":                  "one",
+					"kubernetes.io/cluster_id": "two",
+					"kubernetes.io/role":       "three",
+					"team/a":                   "four",
+					"team/ab":                  "five",
+					"team/c":                   "six",
+				},
+			},
+			expected: map[string]interface{}{
+				"tags": map[string]interface{}{
+					"kubernetes.io/role": "three",
+					"team/ab":            "five",
+					"team/c":             "six",
+				},
+			},
+			fields: [][]Step{
+				Field("tags.*_id"),
+				Field("tags.team?[a-b]"),
+			},
+		},
+		{
+			input: map[string]interface{}{
+				"computed_one": "one",
+				"computed_two": "two",
+				"not_computed": "three",
+				"values": []interface{}{
+					map[string]interface{}{
+						"created.timestamp": "four",
+						"name":              "five",
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"not_computed": "three",
+				"values": []interface{}{
+					map[string]interface{}{
+						"name": "five",
+					},
+				},
+			},
+			fields: [][]Step{
+				Field("/^computed_/"),
+				Field("values.*./.+\\.timestamp$/"),
+			},
+		},
+		{
+			input: map[string]interface{}{
+				"a_timestamp": map[string]interface{}{
+					"keep": true,
+				},
+				"b_timestamp": map[string]interface{}{
+					"keep": false,
+				},
+			},
+			expected: map[string]interface{}{
+				"a_timestamp": map[string]interface{}{
+					"keep": true,
+				},
+			},
+			fields: [][]Step{
+				{
+					{
+						Step: "*_timestamp",
+						Filter: []Filter{
+							{
+								Path:  []string{"keep"},
+								Value: false,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: map[string]interface{}{
+				"id": "root",
+				"resource_changes": []interface{}{
+					map[string]interface{}{
+						"address": "one",
+						"change": map[string]interface{}{
+							"after": map[string]interface{}{
+								"id": "one",
+								"block": []interface{}{
+									map[string]interface{}{
+										"id":   "nested",
+										"name": "nested",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"id": "root",
+				"resource_changes": []interface{}{
+					map[string]interface{}{
+						"address": "one",
+						"change": map[string]interface{}{
+							"after": map[string]interface{}{
+								"block": []interface{}{
+									map[string]interface{}{
+										"name": "nested",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			fields: [][]Step{
+				Field("resource_changes.**.id"),
+			},
+		},
+		{
+			input: map[string]interface{}{
+				"id": "root",
+				"child": map[string]interface{}{
+					"id": "child",
+					"list": []interface{}{
+						"id",
+						[]interface{}{
+							"zero",
+							"one",
+						},
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"child": map[string]interface{}{
+					"list": []interface{}{
+						"id",
+						[]interface{}{
+							"zero",
+						},
+					},
+				},
+			},
+			fields: [][]Step{
+				Field("**.id"),
+				Field("**.1.1"),
+			},
+		},
+		{
+			input: map[string]interface{}{
+				"one": map[string]interface{}{
+					"sensitive": true,
+					"value":     "secret",
+				},
+				"two": map[string]interface{}{
+					"sensitive": false,
+					"value":     "public",
+					"three": map[string]interface{}{
+						"sensitive": true,
+						"value":     "secret",
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"one": map[string]interface{}{
+					"sensitive": true,
+				},
+				"two": map[string]interface{}{
+					"sensitive": false,
+					"value":     "public",
+					"three": map[string]interface{}{
+						"sensitive": true,
+					},
+				},
+			},
+			fields: [][]Step{
+				{
+					{
+						Step: recursiveWildcard,
+						Filter: []Filter{
+							{
+								Path:  []string{"sensitive"},
+								Value: true,
+							},
+						},
+					},
+					{
+						Step: "value",
+					},
+				},
+			},
+		},
+		{
+			input: map[string]interface{}{
+				"map": map[string]interface{}{
+					"one": "one",
+				},
+				"list": []interface{}{
+					"one",
+				},
+			},
+			expected: map[string]interface{}{
+				"map":  map[string]interface{}{},
+				"list": []interface{}{},
+			},
+			fields: [][]Step{
+				Field("map.**"),
+				Field("list.**"),
+			},
+		},
 	}
 	for ix, tc := range tcs {
 		t.Run(fmt.Sprintf("%d", ix), func(t *testing.T) {
@@ -507,3 +739,374 @@ func TestStripJson(t *testing.T) {
 		})
 	}
 }
+
+func TestField(t *testing.T) {
+	tcs := map[string][]string{
+		"one":                      {"one"},
+		"one.two.*":                {"one", "two", "*"},
+		"list.0.*_id":              {"list", "0", "*_id"},
+		"/^computed_/":             {"/^computed_/"},
+		"values./a\\.b/.c":         {"values", "/a\\.b/", "c"},
+		"values./a\\/b.c/.name":    {"values", "/a\\/b.c/", "name"},
+		"tags.kubernetes\\.io/\\*": {"tags", "kubernetes\\.io/\\*"},
+		"values.\\/path/":          {"values", "\\/path/"},
+	}
+	for path, expected := range tcs {
+		t.Run(path, func(t *testing.T) {
+			var actual []string
+			for _, step := range Field(path) {
+				actual = append(actual, step.Step)
+			}
+
+			if fmt.Sprintf("%q", actual) != fmt.Sprintf("%q", expected) {
+				t.Fatalf("expected %q, but found %q", expected, actual)
+			}
+		})
+	}
+}
+
+func TestMatchesKey_Glob(t *testing.T) {
+	tcs := []struct {
+		step    string
+		key     string
+		matches bool
+	}{
+		{step: "*_id", key: "name_id", matches: true},
+		{step: "*_id", key: "kubernetes.io/cluster_id", matches: true},
+		{step: "*_id", key: "name_ids", matches: false},
+		{step: "a?c", key: "a/c", matches: true},
+		{step: "[^a]*", key: "b.c", matches: true},
+		{step: "[^a]*", key: "a.c", matches: false},
+		{step: "[]]*", key: "]x", matches: true},
+		{step: "x\\*[.]", key: "x*.", matches: true},
+		{step: "x\\*[.]", key: "xx.", matches: false},
+		{step: "(a|b)*", key: "(a|b)c", matches: true},
+		{step: "(a|b)*", key: "ac", matches: false},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.step+"/"+tc.key, func(t *testing.T) {
+			matches, err := Step{Step: tc.step}.matchesKey(tc.key)
+			if err != nil {
+				t.Fatalf("matchesKey failed unexpectedly: %v", err)
+			}
+			if matches != tc.matches {
+				t.Errorf("expected %t, but found %t", tc.matches, matches)
+			}
+		})
+	}
+
+	for _, step := range []string{"[a", "[z-a]*"} {
+		if _, err := (Step{Step: step}).matchesKey("a"); err == nil {
+			t.Errorf("expected %s to be an invalid glob pattern", step)
+		}
+	}
+}
+
+func TestStripLiteralKeys(t *testing.T) {
+	input := func() map[string]interface{} {
+		return map[string]interface{}{
+			"kubernetes.io/*": "glob",
+			"kubernetes.io/a": "other",
+			"what?":           "question",
+			"whatX":           "other",
+			"[0]":             "bracket",
+			"/path/":          "slashes",
+			"path":            "other",
+			"back\\slash":     "backslash",
+		}
+	}
+
+	tcs := map[string]string{
+		"kubernetes\\.io/\\*": "kubernetes.io/*",
+		"what\\?":             "what?",
+		"\\[0]":               "[0]",
+		"\\/path/":            "/path/",
+		"back\\\\slash":       "back\\slash",
+	}
+	for path, removed := range tcs {
+		t.Run(path, func(t *testing.T) {
+			actual, err := Strip([][]Step{Field(path)}, input())
+			if err != nil {
+				t.Fatalf("Strip failed unexpectedly: %v", err)
+			}
+
+			// Only the literal key should have been removed, and not any
+			// other keys the path would match as a pattern.
+			expected := input()
+			delete(expected, removed)
+			if diff := cmp.Diff(expected, actual); len(diff) > 0 {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMaskJson(t *testing.T) {
+	tcs := []struct {
+		input    interface{}
+		expected interface{}
+		fields   [][]Step
+	}{
+		{
+			input: map[string]interface{}{
+				"timestamp": "2022-01-01T00:00:00Z",
+				"missing":   nil,
+			},
+			expected: map[string]interface{}{
+				"timestamp": "<masked>",
+				"missing":   nil,
+			},
+			fields: [][]Step{
+				Field("timestamp"),
+				Field("other"),
+			},
+		},
+		{
+			input: []interface{}{
+				map[string]interface{}{
+					"id":   "one",
+					"type": "random_pet",
+				},
+				map[string]interface{}{
+					"id":   "two",
+					"type": "null_resource",
+				},
+				"three",
+			},
+			expected: []interface{}{
+				map[string]interface{}{
+					"id":   "<masked>",
+					"type": "random_pet",
+				},
+				map[string]interface{}{
+					"id":   "two",
+					"type": "null_resource",
+				},
+				"<masked>",
+			},
+			fields: [][]Step{
+				{
+					{
+						Step: wildcard,
+						Filter: []Filter{
+							{
+								Path:  []string{"type"},
+								Value: "random_pet",
+							},
+						},
+					},
+					{
+						Step: "id",
+					},
+				},
+				Field("2"),
+			},
+		},
+		{
+			input: map[string]interface{}{
+				"values": map[string]interface{}{
+					"arn":        "arn:aws:one",
+					"created_at": "2022-01-01T00:00:00Z",
+					"updated_at": "2022-01-01T00:00:00Z",
+					"list":       []interface{}{"one", "two"},
+				},
+			},
+			expected: map[string]interface{}{
+				"values": map[string]interface{}{
+					"arn":        "<masked>",
+					"created_at": "<masked>",
+					"updated_at": "<masked>",
+					"list":       []interface{}{"<masked>", "<masked>"},
+				},
+			},
+			fields: [][]Step{
+				Field("values.arn"),
+				Field("**.*_at"),
+				Field("values.list.*"),
+			},
+		},
+		{
+			input: map[string]interface{}{
+				"string": "one",
+				"number": json.Number("1"),
+				"float":  float64(1),
+				"bool":   true,
+				"object": map[string]interface{}{"one": "one"},
+				"array":  []interface{}{"one"},
+				"null":   nil,
+			},
+			expected: map[string]interface{}{
+				"string": "<masked>",
+				"number": "<masked:number>",
+				"float":  "<masked:number>",
+				"bool":   "<masked:bool>",
+				"object": "<masked:object>",
+				"array":  "<masked:array>",
+				"null":   nil,
+			},
+			fields: [][]Step{
+				Field("*"),
+			},
+		},
+	}
+	for ix, tc := range tcs {
+		t.Run(fmt.Sprintf("%d", ix), func(t *testing.T) {
+			actual, err := Mask(tc.fields, "<masked>", SkipMissingPaths, tc.input)
+			if err != nil {
+				t.Fatalf("call to Mask failed unexpectedly: %v", err)
+			}
+
+			actualStr, err := json.Marshal(actual)
+			if err != nil {
+				t.Fatalf("could not convert actual into bytes: %v", err)
+			}
+
+			expectedStr, err := json.Marshal(tc.expected)
+			if err != nil {
+				t.Fatalf("could not convert expected into bytes: %v", err)
+			}
+
+			if string(actualStr) != string(expectedStr) {
+				t.Fatalf("actual does not equal expected\nexpected:\n\t%s\nactual:\n\t%s\n", string(expectedStr), string(actualStr))
+			}
+		})
+	}
+}
+
+func TestMaskJson_Placeholder(t *testing.T) {
+	tcs := map[string]string{
+		"<timestamp>": "<timestamp:number>",
+		"MASKED":      "MASKED:number",
+	}
+	for placeholder, expected := range tcs {
+		t.Run(placeholder, func(t *testing.T) {
+			actual, err := Mask([][]Step{Field("value")}, placeholder, SkipMissingPaths, map[string]interface{}{"value": float64(1)})
+			if err != nil {
+				t.Fatalf("call to Mask failed unexpectedly: %v", err)
+			}
+
+			if diff := cmp.Diff(map[string]interface{}{"value": expected}, actual); len(diff) > 0 {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStripAndCount(t *testing.T) {
+	input := map[string]interface{}{
+		"resource_changes": []interface{}{
+			map[string]interface{}{"id": "one", "name": "a"},
+			map[string]interface{}{"id": "two", "name": "b"},
+		},
+		"timestamp": "2022-01-01T00:00:00Z",
+	}
+
+	_, counts, err := StripAndCount([][]Step{
+		Field("resource_changes.*.id"),
+		Field("timestamp"),
+		Field("timestmp"),
+		Field("resource_changes.*.missing"),
+		Field("**.name"),
+	}, SkipMissingPaths, input)
+	if err != nil {
+		t.Fatalf("failed to strip: %v", err)
+	}
+
+	if diff := cmp.Diff([]int{2, 1, 0, 0, 2}, counts); len(diff) > 0 {
+		t.Errorf("unexpected counts (-want +got):\n%s", diff)
+	}
+}
+
+func TestStripMissingPaths(t *testing.T) {
+	input := `{
+  "list": [{"id": "one"}, {"id": "two"}, {"id": "three"}],
+  "map": {"key": "value"},
+  "string": "value",
+  "null": null
+}`
+
+	tcs := map[string]struct {
+		field    string
+		expected string
+		error    bool
+
+		// invalid fields fail even when missing paths are skipped.
+		invalid bool
+	}{
+		"negative_leaf":        {field: "list.-1", expected: `{"list": [{"id": "one"}, {"id": "two"}], "map": {"key": "value"}, "string": "value", "null": null}`},
+		"negative_node":        {field: "list.-3.id", expected: `{"list": [{}, {"id": "two"}, {"id": "three"}], "map": {"key": "value"}, "string": "value", "null": null}`},
+		"index_out_of_bounds":  {field: "list.3", error: true},
+		"negative_out_of_list": {field: "list.-4.id", error: true},
+		"missing_leaf":         {field: "map.other", error: true},
+		"missing_node":         {field: "other.key", error: true},
+		"through_string":       {field: "string.key", error: true},
+		"through_null":         {field: "null.key", error: true},
+		"recursive":            {field: "**.5", expected: input},
+		"not_an_index":         {field: "list.first", error: true, invalid: true},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			for _, missing := range []MissingPaths{SkipMissingPaths, ErrorOnMissingPaths} {
+				var data interface{}
+				if err := json.Unmarshal([]byte(input), &data); err != nil {
+					t.Fatalf("failed to unmarshal input: %v", err)
+				}
+
+				actual, _, err := StripAndCount([][]Step{Field(tc.field)}, missing, data)
+
+				if tc.error && (missing == ErrorOnMissingPaths || tc.invalid) {
+					if err == nil {
+						t.Errorf("expected an error with %s", missing)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("unexpected error with %s: %v", missing, err)
+				}
+
+				expected := tc.expected
+				if len(expected) == 0 {
+					expected = input
+				}
+
+				var expectedData interface{}
+				if err := json.Unmarshal([]byte(expected), &expectedData); err != nil {
+					t.Fatalf("failed to unmarshal expected: %v", err)
+				}
+
+				if diff := cmp.Diff(expectedData, actual); len(diff) > 0 {
+					t.Errorf("unexpected diff with %s (-want +got):\n%s", missing, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestStripMissingPaths_Messages(t *testing.T) {
+	decoder := json.NewDecoder(strings.NewReader(`{"count": 1, "enabled": true, "name": "a"}`))
+	decoder.UseNumber()
+
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		t.Fatalf("failed to decode input: %v", err)
+	}
+
+	tcs := map[string]string{
+		"count.value":    "cannot find value within a number value",
+		"count.value.id": "cannot find value within a number value",
+		"enabled.value":  "cannot find value within a bool value",
+		"name.value":     "cannot find value within a string value",
+	}
+	for field, expected := range tcs {
+		t.Run(field, func(t *testing.T) {
+			_, _, err := StripAndCount([][]Step{Field(field)}, ErrorOnMissingPaths, data)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if err.Error() != expected {
+				t.Errorf("expected %q, but found %q", expected, err.Error())
+			}
+		})
+	}
+}
@@ -5,11 +5,12 @@ package terraform
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
+
+	"github.com/hashicorp/terraform-equivalence-testing/internal/files"
 )
 
 type capture struct {
@@ -21,6 +22,10 @@ func (c capture) ToString() string {
 	return c.stdout.String()
 }
 
+func (c capture) StderrToString() string {
+	return c.stderr.String()
+}
+
 func (c capture) ToJson(structured bool) (interface{}, error) {
 	var target []byte
 	if structured {
@@ -36,11 +41,7 @@ func (c capture) ToJson(structured bool) (interface{}, error) {
 		target = c.stdout.Bytes()
 	}
 
-	var data interface{}
-	if err := json.Unmarshal(target, &data); err != nil {
-		return data, err
-	}
-	return data, nil
+	return files.DecodeJson(target)
 }
 
 func (c capture) ToError() error {
@@ -0,0 +1,82 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package terraform
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// Environment describes the environment variables that Terraform commands are
+// executed with.
+//
+// By default, commands inherit the environment of the current process except
+// for the variables matched by excludedVariables. These variables change the
+// outputs of Terraform, so inheriting them would make the outputs depend on
+// whoever executes the tests. Any of them the tests need should be set
+// explicitly using Variables instead. Other TF_ variables, such as
+// TF_CLI_CONFIG_FILE, TF_PLUGIN_CACHE_DIR or TF_TOKEN_*, are still inherited
+// so provider mirrors and credentials keep working.
+type Environment struct {
+	// Variables are set for every command, on top of the base environment.
+	Variables map[string]string
+
+	// Isolated tells the framework not to inherit any environment variables
+	// from the current process, so commands are executed with only the
+	// Variables set here and by the commands themselves.
+	Isolated bool
+}
+
+// excludedVariables are the prefixes of the environment variables that are
+// not inherited from the current process, as they change the outputs of
+// Terraform.
+var excludedVariables = []string{
+	"TF_LOG",
+	"TF_CLI_ARGS",
+	"TF_IN_AUTOMATION",
+	"TF_INPUT",
+	"TF_VAR_",
+	"TF_WORKSPACE",
+	"TF_DATA_DIR",
+}
+
+// environ returns the environment for a command that sets the additional
+// variables, in the KEY=value format expected by exec.Cmd. The returned
+// environment is sorted, so it is the same between runs.
+func (env Environment) environ(variables map[string]string) []string {
+	merged := map[string]string{}
+	if !env.Isolated {
+		for _, variable := range os.Environ() {
+			key, value, _ := strings.Cut(variable, "=")
+			if excluded(key) {
+				continue
+			}
+			merged[key] = value
+		}
+	}
+
+	for key, value := range env.Variables {
+		merged[key] = value
+	}
+	for key, value := range variables {
+		merged[key] = value
+	}
+
+	var environ []string
+	for key, value := range merged {
+		environ = append(environ, key+"="+value)
+	}
+	sort.Strings(environ)
+	return environ
+}
+
+func excluded(key string) bool {
+	for _, prefix := range excludedVariables {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -16,5 +16,20 @@ type Error struct {
 
 // Error makes our Error struct match the standard Go error interface.
 func (e Error) Error() string {
+	if e.Terraform == nil {
+		// Terraform didn't write anything to stderr.
+		return fmt.Sprintf("terraform command (%s) failed (%s)", e.Command, e.Go.Error())
+	}
 	return fmt.Sprintf("terraform command (%s) failed (%s) (%s)", e.Command, e.Go.Error(), e.Terraform.Error())
 }
+
+// TimeoutError is returned when a Terraform command was killed because it, or
+// the test it was part of, ran for longer than the configured timeout.
+type TimeoutError struct {
+	Command string
+}
+
+// Error makes our TimeoutError struct match the standard Go error interface.
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("terraform command (%s) timed out", e.Command)
+}
@@ -0,0 +1,21 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !windows
+
+package terraform
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup makes cmd start in its own process group, and kill the
+// whole group when its context is cancelled. This makes sure any providers
+// started by Terraform are stopped alongside Terraform itself.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,12 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build windows
+
+package terraform
+
+import "os/exec"
+
+// killProcessGroup is a no-op on Windows, where we rely on exec.CommandContext
+// killing the Terraform process directly.
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -5,9 +5,12 @@ package terraform
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"time"
 
 	"github.com/hashicorp/terraform-equivalence-testing/internal/files"
 
@@ -60,6 +63,49 @@ type Command struct {
 	// This field is ignored if CaptureOutput is false or if HasJsonOutput is
 	// false.
 	StreamsJsonOutput bool `json:"streams_json_output"`
+
+	// Timeout is the maximum amount of time this command is allowed to run
+	// for. If the command takes longer than this, it will be killed and the
+	// test will fail with a TimeoutError.
+	//
+	// If Timeout is zero, the command can run for as long as the test itself
+	// is allowed to.
+	Timeout Duration `json:"timeout"`
+
+	// ExpectFailure tells the framework this command is expected to fail. If
+	// the command fails, the test continues and any captured output is
+	// compared like the output of any other command. If the command succeeds,
+	// the test fails instead.
+	//
+	// This can be used to lock in the diagnostics Terraform reports for an
+	// invalid configuration across different versions.
+	ExpectFailure bool `json:"expect_failure"`
+
+	// ExpectedExitCode optionally tells the framework which exit code the
+	// command should fail with. If the command fails with a different exit
+	// code, the test fails.
+	//
+	// This field is ignored if ExpectFailure is false.
+	ExpectedExitCode *int `json:"expected_exit_code"`
+
+	// StderrFileName is the name of the file that the framework should write
+	// the captured stderr output into. Terraform writes its diagnostics,
+	// including warnings and deprecation notices, to stderr unless the command
+	// was asked for JSON output.
+	//
+	// If StderrFileName is empty, stderr is not captured.
+	StderrFileName string `json:"stderr_file_name"`
+
+	// ExitCodeFileName is the name of the file that the framework should
+	// write the exit code of the command into.
+	//
+	// If ExitCodeFileName is empty, the exit code is not captured.
+	ExitCodeFileName string `json:"exit_code_file_name"`
+
+	// Env is a set of additional environment variables to set for this
+	// command only. These override any variables with the same name set by
+	// the test specification.
+	Env map[string]string `json:"env"`
 }
 
 // Terraform is an interface that can execute a single equivalence test within a
@@ -70,7 +116,17 @@ type Terraform interface {
 	// ExecuteTest executes a series of terraform commands in order and returns the
 	// output of the apply and plan steps, the Terraform state, and any additionally
 	// requested files.
-	ExecuteTest(directory string, includeFiles []string, commands ...Command) (map[string]*files.File, error)
+	//
+	// The commands are executed from within directory, and includeFiles are
+	// read relative to directory. ExecuteTest does not modify the working
+	// directory of the current process, so it is safe to call concurrently.
+	//
+	// Any running command is killed if ctx is cancelled or its deadline is
+	// exceeded.
+	//
+	// Every command is executed with the environment described by env, plus
+	// any environment variables set by the command itself.
+	ExecuteTest(ctx context.Context, directory string, env Environment, includeFiles []string, commands ...Command) (map[string]*files.File, error)
 
 	// Version returns the version of the underlying Terraform binary.
 	Version() string
@@ -116,55 +172,50 @@ func (t *terraform) Version() string {
 	return t.version
 }
 
-func (t *terraform) ExecuteTest(directory string, includeFiles []string, commands ...Command) (map[string]*files.File, error) {
-	wd, err := os.Getwd()
-	if err != nil {
-		return nil, err
-	}
-
-	if err := os.Chdir(directory); err != nil {
-		return nil, err
-	}
-	defer os.Chdir(wd)
+func (t *terraform) ExecuteTest(ctx context.Context, directory string, env Environment, includeFiles []string, commands ...Command) (map[string]*files.File, error) {
+	// Every command is executed from within the test directory, rather than
+	// changing the working directory of the whole process. This means
+	// ExecuteTest is safe to call concurrently for different directories.
 
+	var err error
 	savedFiles := map[string]*files.File{}
 	if len(commands) == 0 {
 		// We weren't given custom commands so let's run the default set of
 		// commands.
 
-		if err := t.init(); err != nil {
+		if err := t.init(ctx, directory, env); err != nil {
 			return nil, err
 		}
-		if savedFiles["plan"], err = t.plan(); err != nil {
+		if savedFiles["plan"], err = t.plan(ctx, directory, env); err != nil {
 			return nil, err
 		}
-		if savedFiles["apply.json"], err = t.apply(); err != nil {
+		if savedFiles["apply.json"], err = t.apply(ctx, directory, env); err != nil {
 			return nil, err
 		}
-		if savedFiles["state"], err = t.showState(); err != nil {
+		if savedFiles["state"], err = t.showState(ctx, directory, env); err != nil {
 			return nil, err
 		}
-		if savedFiles["state.json"], err = t.showJsonState(); err != nil {
+		if savedFiles["state.json"], err = t.showJsonState(ctx, directory, env); err != nil {
 			return nil, err
 		}
-		if savedFiles["plan.json"], err = t.showJsonPlan(); err != nil {
+		if savedFiles["plan.json"], err = t.showJsonPlan(ctx, directory, env); err != nil {
 			return nil, err
 		}
 	} else {
 		for _, command := range commands {
-			output, err := t.command(command)
+			outputs, err := t.command(ctx, directory, env, command)
 			if err != nil {
 				return nil, err
 			}
 
-			if output != nil {
-				savedFiles[command.OutputFileName] = output
+			for name, output := range outputs {
+				savedFiles[name] = output
 			}
 		}
 	}
 
 	for _, includeFile := range includeFiles {
-		raw, err := os.ReadFile(includeFile)
+		raw, err := os.ReadFile(filepath.Join(directory, includeFile))
 		if err != nil {
 			return nil, fmt.Errorf("could not read additional file (%s): %v", includeFile, err)
 		}
@@ -176,18 +227,37 @@ func (t *terraform) ExecuteTest(directory string, includeFiles []string, command
 	return savedFiles, nil
 }
 
-func (t *terraform) command(command Command) (*files.File, error) {
-	capture, err := run(exec.Command(t.binary, command.Arguments...), command.Name)
+func (t *terraform) command(ctx context.Context, directory string, env Environment, command Command) (map[string]*files.File, error) {
+	if command.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(command.Timeout))
+		defer cancel()
+	}
+
+	cmd := t.cmd(ctx, directory, env.environ(command.Env), command.Arguments...)
+	capture, err := run(ctx, cmd, command.Name)
+	if command.ExpectFailure {
+		err = expectFailure(command, err)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	outputs := map[string]*files.File{}
+	if len(command.StderrFileName) > 0 {
+		outputs[command.StderrFileName] = files.NewRawFile(capture.StderrToString())
+	}
+	if len(command.ExitCodeFileName) > 0 {
+		outputs[command.ExitCodeFileName] = files.NewRawFile(fmt.Sprintf("%d\n", cmd.ProcessState.ExitCode()))
+	}
+
 	if !command.CaptureOutput {
-		return nil, nil
+		return outputs, nil
 	}
 
 	if !command.HasJsonOutput {
-		return files.NewRawFile(capture.ToString()), nil
+		outputs[command.OutputFileName] = files.NewRawFile(capture.ToString())
+		return outputs, nil
 	}
 
 	var json interface{}
@@ -200,27 +270,62 @@ func (t *terraform) command(command Command) (*files.File, error) {
 			return nil, err
 		}
 	}
-	return files.NewJsonFile(json), nil
+	outputs[command.OutputFileName] = files.NewJsonFile(json)
+	return outputs, nil
 }
 
-func (t *terraform) init() error {
-	_, err := run(exec.Command(t.binary, "init"), "init")
+// expectFailure converts the error returned by a command that was expected to
+// fail. It returns nil if the command failed in the expected way, and an error
+// if the command succeeded or failed in an unexpected way.
+func expectFailure(command Command, err error) error {
+	if err == nil {
+		return Error{
+			Command: command.Name,
+			Go:      errors.New("command succeeded but was expected to fail"),
+		}
+	}
+
+	var tfErr Error
+	if !errors.As(err, &tfErr) {
+		// Timeouts, and failures to start the command at all, are never
+		// expected.
+		return err
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(tfErr.Go, &exitErr) {
+		return err
+	}
+
+	if command.ExpectedExitCode != nil && exitErr.ExitCode() != *command.ExpectedExitCode {
+		return Error{
+			Command:   command.Name,
+			Go:        fmt.Errorf("command failed with exit code %d but was expected to fail with exit code %d", exitErr.ExitCode(), *command.ExpectedExitCode),
+			Terraform: tfErr.Terraform,
+		}
+	}
+
+	return nil
+}
+
+func (t *terraform) init(ctx context.Context, directory string, env Environment) error {
+	_, err := run(ctx, t.cmd(ctx, directory, env.environ(nil), "init"), "init")
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *terraform) plan() (*files.File, error) {
-	capture, err := run(exec.Command(t.binary, "plan", "-out=equivalence_test_plan", "-no-color"), "plan")
+func (t *terraform) plan(ctx context.Context, directory string, env Environment) (*files.File, error) {
+	capture, err := run(ctx, t.cmd(ctx, directory, env.environ(nil), "plan", "-out=equivalence_test_plan", "-no-color"), "plan")
 	if err != nil {
 		return nil, err
 	}
 	return files.NewRawFile(capture.ToString()), nil
 }
 
-func (t *terraform) apply() (*files.File, error) {
-	capture, err := run(exec.Command(t.binary, "apply", "-json", "equivalence_test_plan"), "apply")
+func (t *terraform) apply(ctx context.Context, directory string, env Environment) (*files.File, error) {
+	capture, err := run(ctx, t.cmd(ctx, directory, env.environ(nil), "apply", "-json", "equivalence_test_plan"), "apply")
 	if err != nil {
 		return nil, err
 	}
@@ -232,16 +337,16 @@ func (t *terraform) apply() (*files.File, error) {
 	return files.NewJsonFile(json), nil
 }
 
-func (t *terraform) showState() (*files.File, error) {
-	capture, err := run(exec.Command(t.binary, "show", "-no-color"), "show state")
+func (t *terraform) showState(ctx context.Context, directory string, env Environment) (*files.File, error) {
+	capture, err := run(ctx, t.cmd(ctx, directory, env.environ(nil), "show", "-no-color"), "show state")
 	if err != nil {
 		return nil, err
 	}
 	return files.NewRawFile(capture.ToString()), nil
 }
 
-func (t *terraform) showJsonPlan() (*files.File, error) {
-	capture, err := run(exec.Command(t.binary, "show", "-json", "equivalence_test_plan"), "show json plan")
+func (t *terraform) showJsonPlan(ctx context.Context, directory string, env Environment) (*files.File, error) {
+	capture, err := run(ctx, t.cmd(ctx, directory, env.environ(nil), "show", "-json", "equivalence_test_plan"), "show json plan")
 	if err != nil {
 		return nil, err
 	}
@@ -253,8 +358,8 @@ func (t *terraform) showJsonPlan() (*files.File, error) {
 	return files.NewJsonFile(json), nil
 }
 
-func (t *terraform) showJsonState() (*files.File, error) {
-	capture, err := run(exec.Command(t.binary, "show", "-json"), "show json state")
+func (t *terraform) showJsonState(ctx context.Context, directory string, env Environment) (*files.File, error) {
+	capture, err := run(ctx, t.cmd(ctx, directory, env.environ(nil), "show", "-json"), "show json state")
 	if err != nil {
 		return nil, err
 	}
@@ -266,9 +371,26 @@ func (t *terraform) showJsonState() (*files.File, error) {
 	return files.NewJsonFile(json), nil
 }
 
-func run(cmd *exec.Cmd, command string) (*capture, error) {
+// cmd builds a command that executes the Terraform binary with the given
+// arguments and environment from within directory. The command, and any
+// processes it starts, are killed when ctx is done.
+func (t *terraform) cmd(ctx context.Context, directory string, environ []string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, t.binary, args...)
+	cmd.Dir = directory
+	cmd.Env = environ
+	killProcessGroup(cmd)
+	return cmd
+}
+
+func run(ctx context.Context, cmd *exec.Cmd, command string) (*capture, error) {
 	capture := Capture(cmd)
 	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return capture, TimeoutError{
+				Command: command,
+			}
+		}
+
 		return capture, Error{
 			Command:   command,
 			Go:        err,
@@ -0,0 +1,329 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package terraform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-equivalence-testing/internal/files"
+)
+
+// fakeTerraform writes a shell script that stands in for the Terraform binary
+// and returns a terraform struct that executes it.
+func fakeTerraform(t *testing.T, script string) *terraform {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("fake Terraform binary requires a POSIX shell")
+	}
+
+	binary := filepath.Join(t.TempDir(), "terraform")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("could not write fake terraform binary: %v", err)
+	}
+
+	return &terraform{
+		binary:  binary,
+		version: "0.0.0",
+	}
+}
+
+func TestExecuteTest_Concurrent(t *testing.T) {
+	tf := fakeTerraform(t, "pwd\n")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("could not read working directory: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for ix := 0; ix < 16; ix++ {
+		directory := t.TempDir()
+		if err := os.WriteFile(filepath.Join(directory, "include.txt"), []byte(directory), 0644); err != nil {
+			t.Fatalf("could not write include file: %v", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			outputs, err := tf.ExecuteTest(context.Background(), directory, Environment{}, []string{"include.txt"}, Command{
+				Name:           "pwd",
+				Arguments:      []string{"pwd"},
+				CaptureOutput:  true,
+				OutputFileName: "pwd",
+			})
+			if err != nil {
+				t.Errorf("ExecuteTest failed unexpectedly: %v", err)
+				return
+			}
+
+			if err := checkOutput(outputs, "pwd", directory); err != nil {
+				t.Error(err)
+			}
+			if err := checkOutput(outputs, "include.txt", directory); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	current, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("could not read working directory: %v", err)
+	}
+	if current != wd {
+		t.Fatalf("working directory changed from %s to %s", wd, current)
+	}
+}
+
+func TestExecuteTest_Timeout(t *testing.T) {
+	// The background sleep holds on to stdout, so this only returns quickly if
+	// the whole process group is killed.
+	tf := fakeTerraform(t, "sleep 30 &\nwait\n")
+
+	start := time.Now()
+	_, err := tf.ExecuteTest(context.Background(), t.TempDir(), Environment{}, nil, Command{
+		Name:          "sleep",
+		Arguments:     []string{"sleep"},
+		CaptureOutput: true,
+		Timeout:       Duration(100 * time.Millisecond),
+	})
+
+	var timeoutErr TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected a TimeoutError, but got %v", err)
+	}
+	if timeoutErr.Command != "sleep" {
+		t.Fatalf("expected the sleep command to time out, but got %s", timeoutErr.Command)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("command was not killed promptly, took %s", elapsed)
+	}
+}
+
+func TestExecuteTest_ExpectFailure(t *testing.T) {
+	tf := fakeTerraform(t, "echo 'Planning failed'\necho 'Error: Invalid reference' >&2\nexit 3\n")
+
+	exitCode := func(code int) *int {
+		return &code
+	}
+
+	tcs := map[string]struct {
+		command  Command
+		expected map[string]string
+		err      string
+	}{
+		"any_exit_code": {
+			command: Command{
+				Name:           "plan",
+				Arguments:      []string{"plan"},
+				CaptureOutput:  true,
+				OutputFileName: "plan",
+				ExpectFailure:  true,
+				StderrFileName: "plan.stderr",
+			},
+			expected: map[string]string{
+				"plan":        "Planning failed\n",
+				"plan.stderr": "Error: Invalid reference\n",
+			},
+		},
+		"matching_exit_code": {
+			command: Command{
+				Name:             "plan",
+				Arguments:        []string{"plan"},
+				CaptureOutput:    true,
+				OutputFileName:   "plan",
+				ExpectFailure:    true,
+				ExpectedExitCode: exitCode(3),
+				ExitCodeFileName: "plan.exit_code",
+			},
+			expected: map[string]string{
+				"plan":           "Planning failed\n",
+				"plan.exit_code": "3\n",
+			},
+		},
+		"wrong_exit_code": {
+			command: Command{
+				Name:             "plan",
+				Arguments:        []string{"plan"},
+				ExpectFailure:    true,
+				ExpectedExitCode: exitCode(1),
+			},
+			err: "terraform command (plan) failed (command failed with exit code 3 but was expected to fail with exit code 1) (Error: Invalid reference\n)",
+		},
+		"unexpected_failure": {
+			command: Command{
+				Name:      "plan",
+				Arguments: []string{"plan"},
+			},
+			err: "terraform command (plan) failed (exit status 3) (Error: Invalid reference\n)",
+		},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			outputs, err := tf.ExecuteTest(context.Background(), t.TempDir(), Environment{}, nil, tc.command)
+			if len(tc.err) > 0 {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("expected error %q, but got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExecuteTest failed unexpectedly: %v", err)
+			}
+
+			if len(outputs) != len(tc.expected) {
+				t.Fatalf("expected %d outputs, but found %d", len(tc.expected), len(outputs))
+			}
+			for name, expected := range tc.expected {
+				actual, _ := outputs[name].String()
+				if actual != expected {
+					t.Errorf("expected %s to be %q, but found %q", name, expected, actual)
+				}
+			}
+		})
+	}
+
+	t.Run("unexpected_success", func(t *testing.T) {
+		tf := fakeTerraform(t, "exit 0\n")
+		_, err := tf.ExecuteTest(context.Background(), t.TempDir(), Environment{}, nil, Command{
+			Name:          "plan",
+			Arguments:     []string{"plan"},
+			ExpectFailure: true,
+		})
+		if err == nil || err.Error() != "terraform command (plan) failed (command succeeded but was expected to fail)" {
+			t.Fatalf("expected the command to fail, but got %v", err)
+		}
+	})
+}
+
+func TestExecuteTest_CaptureStderr(t *testing.T) {
+	tf := fakeTerraform(t, "echo 'No changes.'\necho 'Warning: Deprecated attribute' >&2\n")
+
+	outputs, err := tf.ExecuteTest(context.Background(), t.TempDir(), Environment{}, nil, Command{
+		Name:             "plan",
+		Arguments:        []string{"plan"},
+		CaptureOutput:    true,
+		OutputFileName:   "plan",
+		StderrFileName:   "plan.stderr",
+		ExitCodeFileName: "plan.exit_code",
+	})
+	if err != nil {
+		t.Fatalf("ExecuteTest failed unexpectedly: %v", err)
+	}
+
+	expected := map[string]string{
+		"plan":           "No changes.\n",
+		"plan.stderr":    "Warning: Deprecated attribute\n",
+		"plan.exit_code": "0\n",
+	}
+	if len(outputs) != len(expected) {
+		t.Fatalf("expected %d outputs, but found %d", len(expected), len(outputs))
+	}
+	for name, expected := range expected {
+		actual, _ := outputs[name].String()
+		if actual != expected {
+			t.Errorf("expected %s to be %q, but found %q", name, expected, actual)
+		}
+	}
+}
+
+func TestExecuteTest_Environment(t *testing.T) {
+	tf := fakeTerraform(t, "echo \"a=$TF_VAR_a workspace=$TF_WORKSPACE log=$TF_LOG args=$TF_CLI_ARGS_plan config=$TF_CLI_CONFIG_FILE foo=$FOO\"\n")
+
+	t.Setenv("TF_VAR_a", "host")
+	t.Setenv("TF_WORKSPACE", "host")
+	t.Setenv("TF_LOG", "TRACE")
+	t.Setenv("TF_CLI_ARGS_plan", "-refresh=false")
+	t.Setenv("TF_CLI_CONFIG_FILE", "mirror.tfrc")
+	t.Setenv("FOO", "bar")
+
+	tcs := map[string]struct {
+		env      Environment
+		command  map[string]string
+		expected string
+	}{
+		"inherited": {
+			env:      Environment{},
+			expected: "a= workspace= log= args= config=mirror.tfrc foo=bar\n",
+		},
+		"test": {
+			env: Environment{
+				Variables: map[string]string{"TF_VAR_a": "test"},
+			},
+			expected: "a=test workspace= log= args= config=mirror.tfrc foo=bar\n",
+		},
+		"command": {
+			env: Environment{
+				Variables: map[string]string{"TF_VAR_a": "test", "TF_LOG": "INFO"},
+			},
+			command:  map[string]string{"TF_VAR_a": "command"},
+			expected: "a=command workspace= log=INFO args= config=mirror.tfrc foo=bar\n",
+		},
+		"isolated": {
+			env: Environment{
+				Variables: map[string]string{"TF_VAR_a": "test"},
+				Isolated:  true,
+			},
+			expected: "a=test workspace= log= args= config= foo=\n",
+		},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			outputs, err := tf.ExecuteTest(context.Background(), t.TempDir(), tc.env, nil, Command{
+				Name:           "env",
+				Arguments:      []string{"env"},
+				CaptureOutput:  true,
+				OutputFileName: "env",
+				Env:            tc.command,
+			})
+			if err != nil {
+				t.Fatalf("ExecuteTest failed unexpectedly: %v", err)
+			}
+
+			actual, _ := outputs["env"].String()
+			if actual != tc.expected {
+				t.Fatalf("expected %q, but found %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func checkOutput(outputs map[string]*files.File, name, directory string) error {
+	output, ok := outputs[name]
+	if !ok {
+		return fmt.Errorf("missing output file %s", name)
+	}
+
+	actual, ok := output.String()
+	if !ok {
+		return fmt.Errorf("output file %s was not a raw file", name)
+	}
+
+	// Resolve any symlinks in the temporary directory, as pwd reports the
+	// physical directory.
+	expected, err := filepath.EvalSymlinks(directory)
+	if err != nil {
+		return err
+	}
+	actual = strings.TrimSpace(actual)
+	if resolved, err := filepath.EvalSymlinks(actual); err == nil {
+		actual = resolved
+	}
+
+	if actual != expected {
+		return fmt.Errorf("expected %s to contain %s, but found %s", name, expected, actual)
+	}
+	return nil
+}
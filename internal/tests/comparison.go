@@ -0,0 +1,144 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	// JsonComparator compares JSON files structurally, and renders the
+	// differences in the JSON diff format. This is the default.
+	JsonComparator Comparator = "json"
+
+	// PlanComparator summarizes the differences between plan.json files by
+	// resource address.
+	PlanComparator Comparator = "plan"
+
+	// StateComparator summarizes the differences between state.json files by
+	// resource address.
+	StateComparator Comparator = "state"
+)
+
+// Comparator selects how a JSON file is compared against the golden files.
+type Comparator string
+
+func (comparator *Comparator) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch Comparator(value) {
+	case JsonComparator, PlanComparator, StateComparator:
+		*comparator = Comparator(value)
+		return nil
+	default:
+		return fmt.Errorf("unrecognized comparator %q, expected %q, %q or %q", value, JsonComparator, PlanComparator, StateComparator)
+	}
+}
+
+// ComparisonOptions controls how numbers in JSON files are compared.
+//
+// By default, numbers are compared by their floating point values so `1` and
+// `1.0` are equal. If NumbersAsStrings is true, numbers are instead compared by
+// their exact text so `1` and `1.0` differ, and large integers beyond the
+// precision of a float64 are compared exactly. EquateNumberFormats relaxes
+// NumbersAsStrings, so numbers with the same exact value but a different text,
+// eg. `1`, `1.0`, and `1e0`, are still equal.
+//
+// Numbers that are not otherwise equal are still considered equal if they are
+// within AbsoluteTolerance of each other, or if their difference is within
+// RelativeTolerance of the larger magnitude of the two.
+type ComparisonOptions struct {
+	AbsoluteTolerance   float64 `json:"absolute_tolerance"`
+	RelativeTolerance   float64 `json:"relative_tolerance"`
+	NumbersAsStrings    bool    `json:"numbers_as_strings"`
+	EquateNumberFormats bool    `json:"equate_number_formats"`
+}
+
+func (options *ComparisonOptions) UnmarshalJSON(data []byte) error {
+	type comparisonOptions ComparisonOptions
+	if err := json.Unmarshal(data, (*comparisonOptions)(options)); err != nil {
+		return err
+	}
+
+	if options.AbsoluteTolerance < 0 || options.RelativeTolerance < 0 {
+		return errors.New("comparison tolerances cannot be negative")
+	}
+	if options.EquateNumberFormats && !options.NumbersAsStrings {
+		return errors.New("equate_number_formats requires numbers_as_strings")
+	}
+	return nil
+}
+
+// cmpOptions returns the options for cmp.Diff that compare JSON values
+// according to these ComparisonOptions.
+func (options ComparisonOptions) cmpOptions() []cmp.Option {
+	return []cmp.Option{
+		cmp.FilterValues(func(x, y interface{}) bool {
+			_, xOk := x.(json.Number)
+			_, yOk := y.(json.Number)
+			_, xFloat := x.(float64)
+			_, yFloat := y.(float64)
+			return (xOk || xFloat) && (yOk || yFloat)
+		}, cmp.Comparer(options.equalNumbers)),
+	}
+}
+
+// equal compares two JSON values according to these ComparisonOptions.
+func (options ComparisonOptions) equal(x, y interface{}) bool {
+	return cmp.Equal(x, y, options.cmpOptions()...)
+}
+
+// equalNumbers compares two numbers, each of which is either a json.Number or
+// a float64.
+func (options ComparisonOptions) equalNumbers(x, y interface{}) bool {
+	xText, yText := numberText(x), numberText(y)
+	xFloat, xErr := strconv.ParseFloat(xText, 64)
+	yFloat, yErr := strconv.ParseFloat(yText, 64)
+
+	if options.NumbersAsStrings {
+		if xText == yText {
+			return true
+		}
+		if options.EquateNumberFormats {
+			xRat, xOk := new(big.Rat).SetString(xText)
+			yRat, yOk := new(big.Rat).SetString(yText)
+			if xOk && yOk && xRat.Cmp(yRat) == 0 {
+				return true
+			}
+		}
+	} else if xErr == nil && yErr == nil && xFloat == yFloat {
+		return true
+	}
+
+	if xErr != nil || yErr != nil {
+		return false
+	}
+
+	difference := math.Abs(xFloat - yFloat)
+	if options.AbsoluteTolerance > 0 && difference <= options.AbsoluteTolerance {
+		return true
+	}
+	return options.RelativeTolerance > 0 && difference <= options.RelativeTolerance*math.Max(math.Abs(xFloat), math.Abs(yFloat))
+}
+
+func numberText(value interface{}) string {
+	switch value := value.(type) {
+	case json.Number:
+		return value.String()
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	default:
+		return ""
+	}
+}
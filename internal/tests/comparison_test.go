@@ -0,0 +1,81 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hashicorp/terraform-equivalence-testing/internal/files"
+)
+
+func TestComparisonOptions(t *testing.T) {
+	tcs := map[string]struct {
+		options  string
+		golden   string
+		actual   string
+		expected bool
+	}{
+		"default_equal":            {`{}`, `{"a": 1}`, `{"a": 1.0}`, true},
+		"default_different":        {`{}`, `{"a": 1}`, `{"a": 1.000001}`, false},
+		"default_nested":           {`{}`, `{"a": [{"b": 2.5}]}`, `{"a": [{"b": 2.50}]}`, true},
+		"absolute_tolerance":       {`{"absolute_tolerance": 0.01}`, `{"a": 1}`, `{"a": 1.005}`, true},
+		"absolute_tolerance_fails": {`{"absolute_tolerance": 0.01}`, `{"a": 1}`, `{"a": 1.02}`, false},
+		"relative_tolerance":       {`{"relative_tolerance": 0.01}`, `{"a": 1000}`, `{"a": 1009}`, true},
+		"relative_tolerance_fails": {`{"relative_tolerance": 0.01}`, `{"a": 1000}`, `{"a": 1011}`, false},
+		"strings_equal":            {`{"numbers_as_strings": true}`, `{"a": 1}`, `{"a": 1}`, true},
+		"strings_format":           {`{"numbers_as_strings": true}`, `{"a": 1}`, `{"a": 1.0}`, false},
+		"strings_large_integers":   {`{"numbers_as_strings": true}`, `{"a": 9007199254740993}`, `{"a": 9007199254740992}`, false},
+		"strings_equate_formats":   {`{"numbers_as_strings": true, "equate_number_formats": true}`, `{"a": 1}`, `{"a": 1e0}`, true},
+		"strings_equate_precision": {`{"numbers_as_strings": true, "equate_number_formats": true}`, `{"a": 9007199254740993}`, `{"a": 9007199254740992.0}`, false},
+		"strings_tolerance":        {`{"numbers_as_strings": true, "absolute_tolerance": 0.5}`, `{"a": 1}`, `{"a": 1.2}`, true},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var options ComparisonOptions
+			if err := json.Unmarshal([]byte(tc.options), &options); err != nil {
+				t.Fatalf("could not unmarshal options: %v", err)
+			}
+
+			golden, err := files.DecodeJson([]byte(tc.golden))
+			if err != nil {
+				t.Fatalf("could not decode golden: %v", err)
+			}
+			actual, err := files.DecodeJson([]byte(tc.actual))
+			if err != nil {
+				t.Fatalf("could not decode actual: %v", err)
+			}
+
+			if equal := cmp.Equal(golden, actual, options.cmpOptions()...); equal != tc.expected {
+				t.Errorf("expected %t but found %t", tc.expected, equal)
+			}
+		})
+	}
+}
+
+func TestComparisonOptions_Invalid(t *testing.T) {
+	tcs := map[string]string{
+		"negative_tolerance": `{"absolute_tolerance": -1}`,
+		"equate_formats":     `{"equate_number_formats": true}`,
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var options ComparisonOptions
+			if err := json.Unmarshal([]byte(tc), &options); err == nil {
+				t.Errorf("expected an error for %s", tc)
+			}
+		})
+	}
+}
+
+func TestComparator_Invalid(t *testing.T) {
+	var specification TestSpecification
+	if err := json.Unmarshal([]byte(`{"comparators": {"plan.json": "semantic"}}`), &specification); err == nil {
+		t.Errorf("expected an error for an unrecognized comparator")
+	}
+}
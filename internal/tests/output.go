@@ -6,21 +6,62 @@ package tests
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"sync"
 
 	"github.com/google/go-cmp/cmp"
 
+	textdiff "github.com/hashicorp/terraform-equivalence-testing/internal/diff"
 	"github.com/hashicorp/terraform-equivalence-testing/internal/files"
 	strip "github.com/hashicorp/terraform-equivalence-testing/internal/json"
+	"github.com/hashicorp/terraform-equivalence-testing/internal/text"
 )
 
 const (
-	NewFile  string = "(new file)"
-	NoChange string = "(no change)"
+	NewFile     string = "(new file)"
+	NoChange    string = "(no change)"
+	RemovedFile string = "(removed file)"
 )
 
+const (
+	// UnifiedDiff renders the differences between raw text files as a
+	// line-based unified diff.
+	UnifiedDiff string = "unified"
+
+	// CmpDiff renders the differences between files using cmp.Diff, which
+	// compares raw text files as Go strings and JSON files as Go maps and
+	// slices.
+	CmpDiff string = "cmp"
+
+	// PathsDiff renders the differences between JSON files as one line per
+	// change, keyed by the JSON path of the change.
+	PathsDiff string = "paths"
+
+	// PatchDiff renders the differences between JSON files as a JSON Patch
+	// document, as defined by RFC 6902.
+	PatchDiff string = "patch"
+)
+
+// DiffOptions controls how the differences between files are rendered.
+type DiffOptions struct {
+	// Format is the format of the diffs for raw text files, either
+	// UnifiedDiff or CmpDiff. If empty, UnifiedDiff is used.
+	Format string
+
+	// JsonFormat is the format of the diffs for JSON files, either PathsDiff,
+	// PatchDiff or CmpDiff. If empty, PathsDiff is used.
+	JsonFormat string
+
+	// Context is the number of unchanged lines included around each change
+	// in a unified diff.
+	Context int
+}
+
 var (
 	// defaultFields is the set of fields that are ignored by default for any
 	// files by the given names.
@@ -85,39 +126,250 @@ var (
 type TestOutput struct {
 	Test  Test
 	files map[string]*files.File
+
+	// processed caches the result of processing the files, so Files and
+	// Warnings share a single pass over them. It is nil for outputs that
+	// weren't created by newTestOutput, which are processed on every call.
+	processed *processedOutput
+}
+
+type processedOutput struct {
+	once     sync.Once
+	files    map[string]*files.File
+	warnings []Warning
+	err      error
+}
+
+func newTestOutput(test Test, files map[string]*files.File) TestOutput {
+	return TestOutput{
+		Test:      test,
+		files:     files,
+		processed: &processedOutput{},
+	}
+}
+
+// Warning is a problem with the test specification that didn't stop the test
+// case from executing.
+type Warning struct {
+	// File is the name of the output file the warning is about.
+	File    string
+	Message string
+
+	// Unmatched is true if the warning is about an ignore_fields or
+	// ignore_lines entry that didn't match anything, either because the entry
+	// doesn't match anything within the file or because the file wasn't
+	// produced at all.
+	Unmatched bool
+}
+
+func (warning Warning) String() string {
+	return fmt.Sprintf("%s: %s", warning.File, warning.Message)
 }
 
 // Files returns the JSON files that were returned by the test stripped of any
-// unwanted fields.
+// unwanted fields, with any masked fields replaced by their placeholders, and
+// with any generated values rewritten by the normalization patterns.
+//
+// The files held by the TestOutput are not modified, so Files can be called
+// any number of times. The files are only processed once for each test run,
+// and the returned files must not be modified.
 func (output TestOutput) Files() (map[string]*files.File, error) {
+	ret, _, err := output.results()
+	return ret, err
+}
+
+// Warnings returns a warning for every entry in the IgnoreFields or
+// IgnoreLines of the test specification that didn't match anything. These
+// entries have probably been misspelled, or the format of the file has
+// changed since they were written. It also returns a warning for any entries
+// in the test specification that target files of the wrong type.
+//
+// The warnings are found by the same pass over the files as Files.
+func (output TestOutput) Warnings() ([]Warning, error) {
+	_, warnings, err := output.results()
+	return warnings, err
+}
+
+// results returns the processed files and warnings, processing the files if
+// they haven't been already.
+func (output TestOutput) results() (map[string]*files.File, []Warning, error) {
+	if output.processed == nil {
+		return output.process()
+	}
+
+	output.processed.once.Do(func() {
+		output.processed.files, output.processed.warnings, output.processed.err = output.process()
+	})
+	return output.processed.files, output.processed.warnings, output.processed.err
+}
+
+func (output TestOutput) process() (map[string]*files.File, []Warning, error) {
+	normalizer, err := strip.NewNormalizer(output.Test.Specification.Normalize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Process the files in a consistent order, so the normalized tokens are
+	// numbered in the same way every time.
+	var names []string
+	for name := range output.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
 	ret := map[string]*files.File{}
-	for name, file := range output.files {
+	var warnings []Warning
+	for _, name := range names {
+		file := output.files[name]
 		contents, ok := file.Json()
 		if !ok {
-			// We only strip data out of JSON files, so skip this because it is
-			// not a JSON file.
-			ret[name] = file
+			// We only strip fields out of JSON files, so apply the text rules
+			// instead because it is not a JSON file.
+			if len(output.Test.Specification.IgnoreFields[name]) > 0 {
+				warnings = append(warnings, Warning{File: name, Message: "ignore_fields has entries for a file that is not JSON"})
+			}
+			if comparator, ok := output.Test.Specification.Comparators[name]; ok && comparator != JsonComparator {
+				warnings = append(warnings, Warning{File: name, Message: fmt.Sprintf("comparators selects %q for a file that is not JSON", comparator)})
+			}
+
+			contents, _ := file.String()
+			rules := output.Test.Specification.IgnoreLines[name]
+			contents, counts, err := text.Apply(rules, contents)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %v", name, err)
+			}
+			for ix, count := range counts {
+				if count == 0 {
+					warnings = append(warnings, Warning{File: name, Message: fmt.Sprintf("ignore_lines entry %q did not match anything", rules[ix]), Unmatched: true})
+				}
+			}
+
+			if len(output.Test.Specification.Normalize) > 0 {
+				contents = normalizer.String(contents)
+			}
+			ret[name] = files.NewRawFile(contents)
 			continue
 		}
 
-		var ignoreFields [][]strip.Step
-		ignoreFields = append(ignoreFields, defaultFields[name]...)
-		for _, field := range output.Test.Specification.IgnoreFields[name] {
-			ignoreFields = append(ignoreFields, strip.Field(field))
+		if len(output.Test.Specification.IgnoreLines[name]) > 0 {
+			warnings = append(warnings, Warning{File: name, Message: "ignore_lines has entries for a file that is not raw text"})
 		}
 
-		stripped, err := strip.Strip(ignoreFields, contents)
+		stripped, err := strip.Strip(defaultFields[name], copyJson(contents))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		missing := output.Test.Specification.MissingPaths
+		for _, field := range output.Test.Specification.IgnoreFields[name] {
+			var counts []int
+			if stripped, counts, err = strip.StripAndCount([][]strip.Step{field.Steps}, missing, stripped); err != nil {
+				return nil, nil, fmt.Errorf("%s: ignore_fields entry %q failed: %v", name, field, err)
+			}
+			if counts[0] == 0 {
+				warnings = append(warnings, Warning{File: name, Message: fmt.Sprintf("ignore_fields entry %q did not match anything", field), Unmatched: true})
+			}
+		}
+
+		for _, field := range output.Test.Specification.MaskFields[name] {
+			if stripped, err = strip.Mask([][]strip.Step{field.Field.Steps}, field.PlaceholderOrDefault(), missing, stripped); err != nil {
+				return nil, nil, fmt.Errorf("%s: mask_fields entry %q failed: %v", name, field.Field, err)
+			}
 		}
+
+		// Normalize before the final sort, so the tokens are numbered by the
+		// order the values appear in the file rather than by the generated
+		// values themselves, which would change on every run. The unordered
+		// arrays are first put into an order that doesn't depend on the
+		// generated values either, by comparing their items with every
+		// generated value erased. The arrays are then sorted by the
+		// normalized values, in the same way as the golden files are sorted.
+		if len(output.Test.Specification.Normalize) > 0 {
+			if stripped, err = output.sortArraysBy(name, normalizer.Erase, stripped); err != nil {
+				return nil, nil, err
+			}
+			stripped = normalizer.Json(stripped)
+		}
+
+		if stripped, err = output.sortArrays(name, stripped); err != nil {
+			return nil, nil, err
+		}
+
 		ret[name] = files.NewJsonFile(stripped)
 	}
-	return ret, nil
+
+	warnings = append(warnings, missingFileWarnings(output, "ignore_fields", output.Test.Specification.IgnoreFields)...)
+	warnings = append(warnings, missingFileWarnings(output, "ignore_lines", output.Test.Specification.IgnoreLines)...)
+
+	return ret, warnings, nil
+}
+
+// missingFileWarnings returns a warning for each file that has rules in the
+// named section of the test specification, but that wasn't produced by the
+// test.
+func missingFileWarnings[T any](output TestOutput, section string, rules map[string][]T) []Warning {
+	var missing []string
+	for name := range rules {
+		if _, ok := output.files[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+
+	var warnings []Warning
+	for _, name := range missing {
+		warnings = append(warnings, Warning{
+			File:      name,
+			Message:   fmt.Sprintf("%s has entries for a file that was not produced", section),
+			Unmatched: true,
+		})
+	}
+	return warnings
+}
+
+// copyJson returns a deep copy of data, so it can be stripped without
+// modifying the original.
+func copyJson(data interface{}) interface{} {
+	switch node := data.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			ret[key] = copyJson(value)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, 0, len(node))
+		for _, item := range node {
+			ret = append(ret, copyJson(item))
+		}
+		return ret
+	default:
+		return data
+	}
+}
+
+// sortArrays sorts all the unordered arrays the test specification lists for
+// the named file.
+func (output TestOutput) sortArrays(name string, data interface{}) (interface{}, error) {
+	return output.sortArraysBy(name, nil, data)
+}
+
+// sortArraysBy sorts the unordered arrays in the same way as sortArrays, but
+// compares their items by the values returned by view.
+func (output TestOutput) sortArraysBy(name string, view func(interface{}) interface{}, data interface{}) (interface{}, error) {
+	for _, array := range output.Test.Specification.UnorderedArrays[name] {
+		var err error
+		if data, err = strip.SortBy([][]strip.Step{array.Field.Steps}, array.Key, view, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
 }
 
 // ComputeDiff will report the difference between this TestOutput and the output
-// already stored in the golden directory specified by the parameter.
-func (output TestOutput) ComputeDiff(goldens string) (map[string]string, error) {
+// already stored in the golden directory specified by the parameter. The diffs
+// are rendered according to options.
+func (output TestOutput) ComputeDiff(goldens string, options DiffOptions) (map[string]string, error) {
 	newFiles, err := output.Files()
 	if err != nil {
 		return nil, err
@@ -142,35 +394,182 @@ func (output TestOutput) ComputeDiff(goldens string) (map[string]string, error)
 			continue
 		}
 
-		var diff string
+		var oldFile *files.File
 		switch newFile.Ext() {
 		case files.Json:
 			// Then we can marshal the goldenFile into a JSON struct and get
 			// more interesting output.
-			newFileJson, _ := newFile.Json()
-			var oldFileJson interface{}
-			if err := json.Unmarshal(goldenFile, &oldFileJson); err != nil {
+			oldFileJson, err := files.DecodeJson(goldenFile)
+			if err != nil {
 				return nil, err
 			}
-			diff = cmp.Diff(oldFileJson, newFileJson)
+
+			// Sort the unordered arrays in the golden file as well, in case
+			// it was written before they were marked as unordered.
+			if oldFileJson, err = output.sortArrays(name, oldFileJson); err != nil {
+				return nil, err
+			}
+			oldFile = files.NewJsonFile(oldFileJson)
 		case files.Raw:
 			// Then we're just going to do a string comparison between the
 			// goldenFile bytes and newFile.
-			newFileString, _ := newFile.String()
-			diff = cmp.Diff(string(goldenFile), newFileString)
+			oldFile = files.NewRawFile(string(goldenFile))
 		default:
 			return nil, errors.New("found unrecognized file type: " + newFile.Ext())
 		}
 
-		if len(diff) == 0 {
-			ret[name] = NoChange
-		} else {
-			ret[name] = diff
+		if ret[name], err = output.diffFiles(name, oldFile, newFile, options); err != nil {
+			return nil, err
+		}
+	}
+
+	// Finally, find any golden files that the new run didn't produce at all.
+	// These would otherwise sit in the golden directory going stale without
+	// anyone noticing.
+	goldenFiles, err := goldenFileNames(path.Join(goldens, output.Test.Name))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range goldenFiles {
+		if _, ok := newFiles[name]; !ok {
+			ret[name] = RemovedFile
+		}
+	}
+
+	return ret, nil
+}
+
+// goldenFileNames returns the names of all the files within directory, relative
+// to directory and using forward slashes so they match the names of the files
+// in a TestOutput. It returns no names if directory doesn't exist.
+func goldenFileNames(directory string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(directory, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && file == directory {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		name, err := filepath.Rel(directory, file)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(name))
+		return nil
+	})
+	return names, err
+}
+
+// CompareTo will report the difference between this TestOutput and other. This
+// TestOutput is treated as the expected output, and other as the actual
+// output.
+//
+// Both outputs should have been produced by the same Test, executed by
+// different Terraform binaries. The same ignore fields are stripped from both
+// outputs before they are compared.
+func (output TestOutput) CompareTo(other TestOutput, options DiffOptions) (map[string]string, error) {
+	oldFiles, err := output.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	newFiles, err := other.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := map[string]string{}
+	for name, newFile := range newFiles {
+		oldFile, ok := oldFiles[name]
+		if !ok {
+			ret[name] = NewFile
+			continue
+		}
+
+		if ret[name], err = output.diffFiles(name, oldFile, newFile, options); err != nil {
+			return nil, err
+		}
+	}
+
+	for name := range oldFiles {
+		if _, ok := newFiles[name]; !ok {
+			ret[name] = RemovedFile
 		}
 	}
 	return ret, nil
 }
 
+// diffFiles returns the difference between the oldFile and newFile, or
+// NoChange if there are no differences. JSON values are compared according to
+// the comparison options and comparators in the test specification, and the
+// diffs are rendered according to options.
+func (output TestOutput) diffFiles(name string, oldFile, newFile *files.File, options DiffOptions) (string, error) {
+	comparison := output.Test.Specification.Comparison
+
+	if oldFile.Ext() != newFile.Ext() {
+		return "", fmt.Errorf("cannot compare %s file with %s file", oldFile.Ext(), newFile.Ext())
+	}
+
+	var diff string
+	switch newFile.Ext() {
+	case files.Json:
+		oldFileJson, _ := oldFile.Json()
+		newFileJson, _ := newFile.Json()
+		// The plan and state comparators render their own summaries, so they
+		// ignore the JSON diff format.
+		switch output.Test.Specification.Comparators[name] {
+		case PlanComparator:
+			diff = textdiff.Plan(oldFileJson, newFileJson, comparison.equal)
+		case StateComparator:
+			diff = textdiff.State(oldFileJson, newFileJson, comparison.equal)
+		default:
+			var err error
+			if diff, err = diffJson(oldFileJson, newFileJson, comparison, options); err != nil {
+				return "", err
+			}
+		}
+	case files.Raw:
+		oldFileString, _ := oldFile.String()
+		newFileString, _ := newFile.String()
+		switch options.Format {
+		case CmpDiff:
+			diff = cmp.Diff(oldFileString, newFileString)
+		case "", UnifiedDiff:
+			diff = textdiff.Unified("a/"+name, "b/"+name, oldFileString, newFileString, options.Context)
+		default:
+			return "", errors.New("found unrecognized diff format: " + options.Format)
+		}
+	default:
+		return "", errors.New("found unrecognized file type: " + newFile.Ext())
+	}
+
+	if len(diff) == 0 {
+		return NoChange, nil
+	}
+	return diff, nil
+}
+
+// diffJson returns the structural difference between oldJson and newJson,
+// rendered in the JSON diff format from options.
+func diffJson(oldJson, newJson interface{}, comparison ComparisonOptions, options DiffOptions) (string, error) {
+	switch options.JsonFormat {
+	case CmpDiff:
+		return cmp.Diff(oldJson, newJson, comparison.cmpOptions()...), nil
+	case "", PathsDiff:
+		return textdiff.Paths(textdiff.JsonChanges(oldJson, newJson, comparison.equal)), nil
+	case PatchDiff:
+		return textdiff.Patch(textdiff.JsonChanges(oldJson, newJson, comparison.equal))
+	default:
+		return "", errors.New("found unrecognized JSON diff format: " + options.JsonFormat)
+	}
+}
+
 // UpdateGoldenFiles will write out the files for a given TestOutput into a
 // target directory. This will overwrite any files already in the target
 // directory.
@@ -0,0 +1,547 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hashicorp/terraform-equivalence-testing/internal/files"
+	strip "github.com/hashicorp/terraform-equivalence-testing/internal/json"
+)
+
+// writeGoldens writes the given golden files for the named test into a new
+// temporary goldens directory, and returns the goldens directory.
+func writeGoldens(t *testing.T, test string, goldens map[string]string) string {
+	t.Helper()
+
+	directory := t.TempDir()
+	for name, contents := range goldens {
+		target := filepath.Join(directory, test, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			t.Fatalf("could not create golden directory: %v", err)
+		}
+		if err := os.WriteFile(target, []byte(contents), os.ModePerm); err != nil {
+			t.Fatalf("could not write golden file: %v", err)
+		}
+	}
+	return directory
+}
+
+func TestComputeDiff(t *testing.T) {
+	goldens := writeGoldens(t, "test", map[string]string{
+		"plan":                "no changes",
+		"state.json":          `{"values": {}}`,
+		"nested/renamed.json": `{}`,
+	})
+
+	output := TestOutput{
+		Test: Test{Name: "test"},
+		files: map[string]*files.File{
+			"plan":        files.NewRawFile("no changes"),
+			"state.json":  files.NewJsonFile(map[string]interface{}{"values": map[string]interface{}{"id": "one"}}),
+			"output.json": files.NewJsonFile([]interface{}{}),
+		},
+	}
+
+	actual, err := output.ComputeDiff(goldens, DiffOptions{})
+	if err != nil {
+		t.Fatalf("ComputeDiff failed unexpectedly: %v", err)
+	}
+
+	if len(actual) != 4 {
+		t.Fatalf("expected 4 files, but found %d: %v", len(actual), actual)
+	}
+	if actual["plan"] != NoChange {
+		t.Errorf("expected plan to have no change, but found %q", actual["plan"])
+	}
+	if actual["output.json"] != NewFile {
+		t.Errorf("expected output.json to be a new file, but found %q", actual["output.json"])
+	}
+	if actual["nested/renamed.json"] != RemovedFile {
+		t.Errorf("expected nested/renamed.json to be a removed file, but found %q", actual["nested/renamed.json"])
+	}
+	if diff := actual["state.json"]; diff == NoChange || diff == NewFile || diff == RemovedFile {
+		t.Errorf("expected state.json to have diffs, but found %q", diff)
+	}
+}
+
+func TestComputeDiff_NoGoldens(t *testing.T) {
+	output := TestOutput{
+		Test: Test{Name: "test"},
+		files: map[string]*files.File{
+			"plan": files.NewRawFile("no changes"),
+		},
+	}
+
+	actual, err := output.ComputeDiff(t.TempDir(), DiffOptions{})
+	if err != nil {
+		t.Fatalf("ComputeDiff failed unexpectedly: %v", err)
+	}
+
+	expected := map[string]string{
+		"plan": NewFile,
+	}
+	if diff := cmp.Diff(expected, actual); len(diff) > 0 {
+		t.Fatalf("unexpected diffs (-want +got):\n%s", diff)
+	}
+}
+
+func TestCompareTo(t *testing.T) {
+	var specification TestSpecification
+	if err := json.Unmarshal([]byte(`{"ignore_fields": {"state.json": ["values.id"]}}`), &specification); err != nil {
+		t.Fatalf("could not unmarshal specification: %v", err)
+	}
+	test := Test{Name: "test", Specification: specification}
+
+	expected := TestOutput{
+		Test: test,
+		files: map[string]*files.File{
+			"plan":       files.NewRawFile("no changes"),
+			"state.json": files.NewJsonFile(map[string]interface{}{"values": map[string]interface{}{"id": "one", "name": "a"}}),
+			"old.json":   files.NewJsonFile([]interface{}{}),
+		},
+	}
+	actual := TestOutput{
+		Test: test,
+		files: map[string]*files.File{
+			"plan":       files.NewRawFile("no changes"),
+			"state.json": files.NewJsonFile(map[string]interface{}{"values": map[string]interface{}{"id": "two", "name": "a"}}),
+			"new.json":   files.NewJsonFile([]interface{}{}),
+		},
+	}
+
+	diffs, err := expected.CompareTo(actual, DiffOptions{})
+	if err != nil {
+		t.Fatalf("CompareTo failed unexpectedly: %v", err)
+	}
+
+	// The ids are stripped from both outputs, so state.json has no diffs.
+	want := map[string]string{
+		"plan":       NoChange,
+		"state.json": NoChange,
+		"old.json":   RemovedFile,
+		"new.json":   NewFile,
+	}
+	if diff := cmp.Diff(want, diffs); len(diff) > 0 {
+		t.Errorf("unexpected diffs (-want +got):\n%s", diff)
+	}
+}
+
+func TestFiles_Normalize(t *testing.T) {
+	id := "3f0c1d9e-5d2a-4b57-9a8e-2f1b7c6d5e4a"
+
+	output := TestOutput{
+		Test: Test{
+			Name: "test",
+			Specification: TestSpecification{
+				Normalize: []strip.Pattern{{Name: "uuid"}},
+			},
+		},
+		files: map[string]*files.File{
+			"plan":       files.NewRawFile("id = " + id),
+			"plan.json":  files.NewJsonFile(map[string]interface{}{"id": "8a7b6c5d-1234-4abc-9def-0123456789ab"}),
+			"state.json": files.NewJsonFile(map[string]interface{}{"id": id}),
+		},
+	}
+
+	actual, err := output.Files()
+	if err != nil {
+		t.Fatalf("Files failed unexpectedly: %v", err)
+	}
+
+	plan, _ := actual["plan"].String()
+	planJson, _ := actual["plan.json"].Json()
+	state, _ := actual["state.json"].Json()
+
+	if plan != "id = <uuid-1>" {
+		t.Errorf("unexpected plan %q", plan)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"id": "<uuid-2>"}, planJson); len(diff) > 0 {
+		t.Errorf("unexpected plan.json (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"id": "<uuid-1>"}, state); len(diff) > 0 {
+		t.Errorf("unexpected state.json (-want +got):\n%s", diff)
+	}
+}
+
+func TestComputeDiff_UnorderedArrays(t *testing.T) {
+	goldens := writeGoldens(t, "test", map[string]string{
+		"plan.json": `{"resource_changes": [{"address": "b"}, {"address": "a"}], "order": [1, 2]}`,
+	})
+
+	output := TestOutput{
+		Test: Test{
+			Name: "test",
+			Specification: TestSpecification{
+				UnorderedArrays: map[string][]UnorderedArray{
+					"plan.json": {
+						{
+							Field: IgnoreField{Steps: strip.Field("resource_changes")},
+							Key:   []string{"address"},
+						},
+					},
+				},
+			},
+		},
+		files: map[string]*files.File{
+			"plan.json": files.NewJsonFile(map[string]interface{}{
+				"resource_changes": []interface{}{
+					map[string]interface{}{"address": "a"},
+					map[string]interface{}{"address": "b"},
+				},
+				"order": []interface{}{float64(2), float64(1)},
+			}),
+		},
+	}
+
+	actual, err := output.ComputeDiff(goldens, DiffOptions{})
+	if err != nil {
+		t.Fatalf("ComputeDiff failed unexpectedly: %v", err)
+	}
+
+	// The resource_changes are unordered, so only the order list should be
+	// reported as changed.
+	expected := "order[0]: 1 -> 2\norder[1]: 2 -> 1\n"
+	if actual["plan.json"] != expected {
+		t.Errorf("expected only the order list to have changed (%q), but found %q", expected, actual["plan.json"])
+	}
+}
+
+func TestFiles_UnorderedArraysNormalize(t *testing.T) {
+	var specification TestSpecification
+	if err := json.Unmarshal([]byte(`{
+  "normalize": [{"name": "uuid"}],
+  "unordered_arrays": {
+    "state.json": [{"field": "resources"}, {"field": "uuids"}]
+  }
+}`), &specification); err != nil {
+		t.Fatalf("could not unmarshal specification: %v", err)
+	}
+
+	// run produces the same state as a real run would, with the given random
+	// ids and with the resources in the given order.
+	run := func(first, second string, reversed bool) TestOutput {
+		resources := []interface{}{
+			map[string]interface{}{"name": "first", "id": first},
+			map[string]interface{}{"name": "second", "id": second, "depends_on": first},
+		}
+		ids := []interface{}{first, second}
+		if reversed {
+			resources[0], resources[1] = resources[1], resources[0]
+			ids[0], ids[1] = ids[1], ids[0]
+		}
+
+		return TestOutput{
+			Test: Test{Name: "test", Specification: specification},
+			files: map[string]*files.File{
+				"state.json": files.NewJsonFile(map[string]interface{}{
+					"resources": resources,
+					"uuids":     ids,
+				}),
+			},
+		}
+	}
+
+	// The ids sort in the opposite order in each run, and the second run
+	// produces the resources in the opposite order as well.
+	runs := []TestOutput{
+		run("1a1a1a1a-0000-4000-8000-000000000000", "2b2b2b2b-0000-4000-8000-000000000000", false),
+		run("9f9f9f9f-0000-4000-8000-000000000000", "0e0e0e0e-0000-4000-8000-000000000000", true),
+	}
+
+	var results []interface{}
+	for _, output := range runs {
+		actual, err := output.Files()
+		if err != nil {
+			t.Fatalf("Files failed unexpectedly: %v", err)
+		}
+		contents, _ := actual["state.json"].Json()
+		results = append(results, contents)
+	}
+
+	// The resources are ordered by their contents with the ids erased, so
+	// the second resource comes first in both runs and its depends_on is
+	// normalized first.
+	expected := map[string]interface{}{
+		"resources": []interface{}{
+			map[string]interface{}{"name": "second", "id": "<uuid-2>", "depends_on": "<uuid-1>"},
+			map[string]interface{}{"name": "first", "id": "<uuid-1>"},
+		},
+		"uuids": []interface{}{"<uuid-1>", "<uuid-2>"},
+	}
+	for ix, result := range results {
+		if diff := cmp.Diff(expected, result); len(diff) > 0 {
+			t.Errorf("unexpected state.json for run %d (-want +got):\n%s", ix, diff)
+		}
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	var specification TestSpecification
+	if err := json.Unmarshal([]byte(`{
+  "ignore_fields": {
+    "plan": ["id"],
+    "plan.json": ["format_version", "resource_changes.*.chnage"],
+    "state.json": ["values"]
+  }
+}`), &specification); err != nil {
+		t.Fatalf("could not unmarshal specification: %v", err)
+	}
+
+	plan := map[string]interface{}{
+		"format_version": "1.2",
+		"resource_changes": []interface{}{
+			map[string]interface{}{"change": map[string]interface{}{}},
+		},
+	}
+
+	output := TestOutput{
+		Test: Test{
+			Name:          "test",
+			Specification: specification,
+		},
+		files: map[string]*files.File{
+			"plan":      files.NewRawFile("no changes"),
+			"plan.json": files.NewJsonFile(plan),
+		},
+	}
+
+	warnings, err := output.Warnings()
+	if err != nil {
+		t.Fatalf("Warnings failed unexpectedly: %v", err)
+	}
+
+	expected := []Warning{
+		{File: "plan", Message: "ignore_fields has entries for a file that is not JSON"},
+		{File: "plan.json", Message: `ignore_fields entry "resource_changes.*.chnage" did not match anything`, Unmatched: true},
+		{File: "state.json", Message: "ignore_fields has entries for a file that was not produced", Unmatched: true},
+	}
+	if diff := cmp.Diff(expected, warnings); len(diff) > 0 {
+		t.Errorf("unexpected warnings (-want +got):\n%s", diff)
+	}
+
+	// Computing the warnings shouldn't have stripped anything from the
+	// original files.
+	if _, ok := plan["format_version"]; !ok {
+		t.Errorf("expected the original file to be unmodified")
+	}
+}
+
+func TestWarnings_SinglePass(t *testing.T) {
+	var specification TestSpecification
+	if err := json.Unmarshal([]byte(`{
+  "ignore_fields": {"plan.json": ["missing"]}
+}`), &specification); err != nil {
+		t.Fatalf("failed to parse specification: %v", err)
+	}
+
+	output := newTestOutput(Test{Name: "test", Specification: specification}, map[string]*files.File{
+		"plan.json": files.NewJsonFile(map[string]interface{}{"present": true}),
+	})
+
+	first, err := output.Files()
+	if err != nil {
+		t.Fatalf("Files failed unexpectedly: %v", err)
+	}
+	warnings, err := output.Warnings()
+	if err != nil {
+		t.Fatalf("Warnings failed unexpectedly: %v", err)
+	}
+	second, err := output.Files()
+	if err != nil {
+		t.Fatalf("Files failed unexpectedly: %v", err)
+	}
+
+	// The files should only have been processed once, so both calls to Files
+	// return the same file.
+	if first["plan.json"] != second["plan.json"] {
+		t.Errorf("expected the files to be processed once")
+	}
+
+	expected := []Warning{
+		{File: "plan.json", Message: `ignore_fields entry "missing" did not match anything`, Unmatched: true},
+	}
+	if diff := cmp.Diff(expected, warnings); len(diff) > 0 {
+		t.Errorf("unexpected warnings (-want +got):\n%s", diff)
+	}
+}
+
+func TestFiles_IgnoreLines(t *testing.T) {
+	var specification TestSpecification
+	if err := json.Unmarshal([]byte(`{
+  "ignore_lines": {
+    "plan": [
+      {"remove": "^Terraform v"},
+      {"replace": "after [0-9]+s", "with": "after <elapsed>"},
+      {"remove": "^Warning:"}
+    ],
+    "plan.json": [{"remove": "^Terraform v"}]
+  }
+}`), &specification); err != nil {
+		t.Fatalf("could not unmarshal specification: %v", err)
+	}
+
+	output := TestOutput{
+		Test: Test{
+			Name:          "test",
+			Specification: specification,
+		},
+		files: map[string]*files.File{
+			"plan":      files.NewRawFile("Terraform v1.5.0\nCreation complete after 3s\n"),
+			"plan.json": files.NewJsonFile(map[string]interface{}{}),
+		},
+	}
+
+	actual, err := output.Files()
+	if err != nil {
+		t.Fatalf("Files failed unexpectedly: %v", err)
+	}
+
+	if plan, _ := actual["plan"].String(); plan != "Creation complete after <elapsed>\n" {
+		t.Errorf("unexpected plan %q", plan)
+	}
+
+	warnings, err := output.Warnings()
+	if err != nil {
+		t.Fatalf("Warnings failed unexpectedly: %v", err)
+	}
+
+	expected := []Warning{
+		{File: "plan", Message: `ignore_lines entry "remove ^Warning:" did not match anything`, Unmatched: true},
+		{File: "plan.json", Message: "ignore_lines has entries for a file that is not raw text"},
+	}
+	if diff := cmp.Diff(expected, warnings); len(diff) > 0 {
+		t.Errorf("unexpected warnings (-want +got):\n%s", diff)
+	}
+}
+
+func TestComputeDiff_RawFormats(t *testing.T) {
+	goldens := writeGoldens(t, "test", map[string]string{
+		"plan": "one\ntwo\nthree\n",
+	})
+
+	output := TestOutput{
+		Test: Test{Name: "test"},
+		files: map[string]*files.File{
+			"plan": files.NewRawFile("one\n2\nthree\n"),
+		},
+	}
+
+	unified, err := output.ComputeDiff(goldens, DiffOptions{Format: UnifiedDiff, Context: 1})
+	if err != nil {
+		t.Fatalf("ComputeDiff failed unexpectedly: %v", err)
+	}
+
+	expected := "--- a/plan\n+++ b/plan\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n"
+	if unified["plan"] != expected {
+		t.Errorf("expected unified diff %q, but found %q", expected, unified["plan"])
+	}
+
+	cmpDiff, err := output.ComputeDiff(goldens, DiffOptions{Format: CmpDiff})
+	if err != nil {
+		t.Fatalf("ComputeDiff failed unexpectedly: %v", err)
+	}
+
+	if expected := cmp.Diff("one\ntwo\nthree\n", "one\n2\nthree\n"); cmpDiff["plan"] != expected {
+		t.Errorf("expected cmp diff %q, but found %q", expected, cmpDiff["plan"])
+	}
+}
+
+func TestComputeDiff_PlanComparator(t *testing.T) {
+	goldens := writeGoldens(t, "test", map[string]string{
+		"plan.json": `{"resource_changes": [{"address": "a", "change": {"actions": ["update"], "after": {"length": 1}}}]}`,
+	})
+
+	var specification TestSpecification
+	if err := json.Unmarshal([]byte(`{"comparators": {"plan.json": "plan", "plan": "plan"}}`), &specification); err != nil {
+		t.Fatalf("could not unmarshal specification: %v", err)
+	}
+
+	output := TestOutput{
+		Test: Test{
+			Name:          "test",
+			Specification: specification,
+		},
+		files: map[string]*files.File{
+			"plan": files.NewRawFile("no changes"),
+			"plan.json": files.NewJsonFile(map[string]interface{}{
+				"resource_changes": []interface{}{
+					map[string]interface{}{
+						"address": "a",
+						"change": map[string]interface{}{
+							"actions": []interface{}{"delete", "create"},
+							"after":   map[string]interface{}{"length": json.Number("2")},
+						},
+					},
+				},
+			}),
+		},
+	}
+
+	// The plan comparator ignores the JSON diff format.
+	actual, err := output.ComputeDiff(goldens, DiffOptions{JsonFormat: PatchDiff})
+	if err != nil {
+		t.Fatalf("ComputeDiff failed unexpectedly: %v", err)
+	}
+
+	expected := "a: actions: [\"update\"] -> [\"delete\",\"create\"]\na: after.length: 1 -> 2\n"
+	if actual["plan.json"] != expected {
+		t.Errorf("expected %q but found %q", expected, actual["plan.json"])
+	}
+
+	warnings, err := output.Warnings()
+	if err != nil {
+		t.Fatalf("Warnings failed unexpectedly: %v", err)
+	}
+	if diff := cmp.Diff([]Warning{{File: "plan", Message: `comparators selects "plan" for a file that is not JSON`}}, warnings); len(diff) > 0 {
+		t.Errorf("unexpected warnings (-want +got):\n%s", diff)
+	}
+}
+
+func TestComputeDiff_StateComparator(t *testing.T) {
+	goldens := writeGoldens(t, "test", map[string]string{
+		"state.json": `{"values": {"root_module": {"resources": [{"address": "a", "values": {"id": "x"}}, {"address": "b", "values": {"id": "y"}}]}}}`,
+	})
+
+	var specification TestSpecification
+	if err := json.Unmarshal([]byte(`{"comparators": {"state.json": "state"}}`), &specification); err != nil {
+		t.Fatalf("could not unmarshal specification: %v", err)
+	}
+
+	output := TestOutput{
+		Test: Test{
+			Name:          "test",
+			Specification: specification,
+		},
+		files: map[string]*files.File{
+			"state.json": files.NewJsonFile(map[string]interface{}{
+				"values": map[string]interface{}{
+					"root_module": map[string]interface{}{
+						"resources": []interface{}{
+							map[string]interface{}{"address": "b", "values": map[string]interface{}{"id": "z"}},
+							map[string]interface{}{"address": "a", "values": map[string]interface{}{"id": "x"}},
+						},
+					},
+				},
+			}),
+		},
+	}
+
+	actual, err := output.ComputeDiff(goldens, DiffOptions{})
+	if err != nil {
+		t.Fatalf("ComputeDiff failed unexpectedly: %v", err)
+	}
+
+	// The resources were reordered, so only the changed attribute should be
+	// reported.
+	expected := "b: values.id: \"y\" -> \"z\"\n"
+	if actual["state.json"] != expected {
+		t.Errorf("expected %q but found %q", expected, actual["state.json"])
+	}
+}
@@ -3,7 +3,16 @@
 
 package tests
 
-import "github.com/hashicorp/terraform-equivalence-testing/internal/terraform"
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	strip "github.com/hashicorp/terraform-equivalence-testing/internal/json"
+	"github.com/hashicorp/terraform-equivalence-testing/internal/terraform"
+	"github.com/hashicorp/terraform-equivalence-testing/internal/text"
+)
 
 // TestSpecification is a struct that provides the specification for a given
 // test case.
@@ -15,12 +24,202 @@ import "github.com/hashicorp/terraform-equivalence-testing/internal/terraform"
 // Each test also has a set of JSON fields for each file that should be ignored
 // when updating or diffing, these are specified in the IgnoreFields field.
 type TestSpecification struct {
-	IncludeFiles []string            `json:"include_files"`
-	IgnoreFields map[string][]string `json:"ignore_fields"`
+	IncludeFiles []string                 `json:"include_files"`
+	IgnoreFields map[string][]IgnoreField `json:"ignore_fields"`
+
+	// MaskFields is a set of JSON fields for each file whose values should be
+	// replaced with a stable placeholder, instead of being removed entirely.
+	// This means the golden files still record that the field was present,
+	// while ignoring its actual value.
+	MaskFields map[string][]MaskField `json:"mask_fields"`
+
+	// IgnoreLines is a set of line-oriented rules for each raw text file, such
+	// as the human-readable plan, that remove or rewrite any unwanted lines.
+	IgnoreLines map[string][]text.Rule `json:"ignore_lines"`
+
+	// MissingPaths controls whether the entries in IgnoreFields and MaskFields
+	// that reference keys or indexes that don't exist are skipped, which is
+	// the default, or fail the test.
+	MissingPaths strip.MissingPaths `json:"missing_paths"`
+
+	// UnorderedArrays is a set of JSON arrays for each file that should be
+	// compared as unordered sets. The arrays are sorted before they are
+	// compared against, or written into, the golden files.
+	UnorderedArrays map[string][]UnorderedArray `json:"unordered_arrays"`
+
+	// Normalize is a list of patterns matching generated values, such as
+	// UUIDs, that should be rewritten into sequential tokens like <uuid-1>.
+	// The same value is rewritten into the same token across every file of
+	// the test case, so references between the files can still be tested.
+	Normalize []strip.Pattern `json:"normalize"`
+
+	// Comparison controls how the values in JSON files are compared against
+	// the golden files.
+	Comparison ComparisonOptions `json:"comparison"`
+
+	// Comparators selects how each JSON file is compared against the golden
+	// files. Files without an entry are compared structurally, while the
+	// "plan" and "state" comparators summarize the differences between
+	// plan.json and state.json files by resource address.
+	Comparators map[string]Comparator `json:"comparators"`
 
 	// If Commands is empty, then we will execute a default set of commands:
 	// [init, plan, apply, show, show plan]. Otherwise, these are the set of
 	// commands that should be executed by the equivalence test framework for
 	// this test case.
 	Commands []terraform.Command `json:"commands"`
+
+	// Timeout is the maximum amount of time the whole test case is allowed to
+	// run for. If zero, the timeout provided by the --timeout flag is used
+	// instead.
+	Timeout terraform.Duration `json:"timeout"`
+
+	// Env is a set of environment variables to set for every command executed
+	// by this test case, eg. TF_VAR_*, TF_LOG or TF_CLI_ARGS.
+	//
+	// Commands inherit the environment of the equivalence test binary, except
+	// for the variables that change the output of Terraform, such as TF_VAR_*,
+	// TF_LOG or TF_CLI_ARGS. If IsolateEnv is true, commands inherit nothing and are
+	// executed with only the variables in Env.
+	Env        map[string]string `json:"env"`
+	IsolateEnv bool              `json:"isolate_env"`
+}
+
+// TimeoutOrDefault returns the Timeout for this test case, or fallback if the
+// test case didn't specify a timeout.
+func (spec TestSpecification) TimeoutOrDefault(fallback time.Duration) time.Duration {
+	if spec.Timeout > 0 {
+		return time.Duration(spec.Timeout)
+	}
+	return fallback
+}
+
+// DefaultPlaceholder is the value masked fields are replaced with if their
+// MaskField doesn't specify a placeholder. Masked values that aren't strings
+// have their type added to the placeholder, eg. `<masked:number>`.
+const DefaultPlaceholder = "<masked>"
+
+// MaskField is a single field whose value should be replaced with a
+// placeholder in a JSON file.
+//
+// The Field accepts the same formats as the entries in IgnoreFields:
+//
+//	{"field": "*.@timestamp", "placeholder": "<timestamp>"}
+type MaskField struct {
+	Field       IgnoreField `json:"field"`
+	Placeholder string      `json:"placeholder"`
+}
+
+func (field *MaskField) UnmarshalJSON(data []byte) error {
+	type maskField MaskField
+	if err := json.Unmarshal(data, (*maskField)(field)); err != nil {
+		return err
+	}
+
+	if len(field.Field.Steps) == 0 {
+		return errors.New("mask_fields entries must specify a field")
+	}
+	return nil
+}
+
+// PlaceholderOrDefault returns the Placeholder for this field, or
+// DefaultPlaceholder if it wasn't set.
+func (field MaskField) PlaceholderOrDefault() string {
+	if len(field.Placeholder) > 0 {
+		return field.Placeholder
+	}
+	return DefaultPlaceholder
+}
+
+// UnorderedArray is a single JSON array whose items can be produced in any
+// order.
+//
+// The Field accepts the same formats as the entries in IgnoreFields, and must
+// select the array itself. If Key is set, it is a path within each item of the
+// array and the items are sorted by the value found there, otherwise the items
+// are sorted by their entire contents:
+//
+//	{"field": "resource_changes", "key": ["address"]}
+type UnorderedArray struct {
+	Field IgnoreField `json:"field"`
+	Key   []string    `json:"key"`
+}
+
+func (array *UnorderedArray) UnmarshalJSON(data []byte) error {
+	type unorderedArray UnorderedArray
+	if err := json.Unmarshal(data, (*unorderedArray)(array)); err != nil {
+		return err
+	}
+
+	if len(array.Field.Steps) == 0 {
+		return errors.New("unordered_arrays entries must specify a field")
+	}
+	return nil
+}
+
+// IgnoreField is a single field that should be stripped from a JSON file.
+//
+// In the test specification, an IgnoreField is either a dotted path string as
+// accepted by strip.Field, or an object containing a list of steps:
+//
+//	{
+//	  "steps": [
+//	    {"step": "*", "filter": [{"path": ["type"], "value": "apply_progress"}]},
+//	    "@message"
+//	  ]
+//	}
+//
+// Each step is either a string, which is used as a single step without being
+// split on dots, or an object matching strip.Step. This gives test authors the
+// same filters that are available to the default ignored fields.
+type IgnoreField struct {
+	Steps []strip.Step
+}
+
+// String returns the steps of this field as a dotted path. Any filters on the
+// steps are summarized rather than written out in full.
+func (field IgnoreField) String() string {
+	var steps []string
+	filtered := false
+	for _, step := range field.Steps {
+		steps = append(steps, step.Step)
+		filtered = filtered || len(step.Filter) > 0
+	}
+
+	path := strings.Join(steps, ".")
+	if filtered {
+		return path + " (with filters)"
+	}
+	return path
+}
+
+func (field *IgnoreField) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		field.Steps = strip.Field(path)
+		return nil
+	}
+
+	var rule struct {
+		Steps []json.RawMessage `json:"steps"`
+	}
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return err
+	}
+
+	if len(rule.Steps) == 0 {
+		return errors.New("ignore_fields entries must be a string or an object with at least one step")
+	}
+
+	field.Steps = nil
+	for _, raw := range rule.Steps {
+		var step strip.Step
+		if err := json.Unmarshal(raw, &step.Step); err != nil {
+			if err := json.Unmarshal(raw, &step); err != nil {
+				return err
+			}
+		}
+		field.Steps = append(field.Steps, step)
+	}
+	return nil
 }
@@ -0,0 +1,180 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hashicorp/terraform-equivalence-testing/internal/files"
+)
+
+func TestIgnoreFields(t *testing.T) {
+	var specification TestSpecification
+	if err := json.Unmarshal([]byte(`{
+  "ignore_fields": {
+    "output.json": [
+      "*.@timestamp",
+      {
+        "steps": [
+          {"step": "*", "filter": [{"path": ["type"], "value": "apply_progress"}]},
+          "@message"
+        ]
+      }
+    ]
+  }
+}`), &specification); err != nil {
+		t.Fatalf("could not unmarshal specification: %v", err)
+	}
+
+	output := TestOutput{
+		Test: Test{
+			Name:          "test",
+			Specification: specification,
+		},
+		files: map[string]*files.File{
+			"output.json": files.NewJsonFile([]interface{}{
+				map[string]interface{}{
+					"@message":   "Applying...",
+					"@timestamp": "2022-01-01T00:00:00Z",
+					"type":       "apply_progress",
+				},
+				map[string]interface{}{
+					"@message":   "Apply complete!",
+					"@timestamp": "2022-01-01T00:00:01Z",
+					"type":       "apply_complete",
+				},
+			}),
+		},
+	}
+
+	actual, err := output.Files()
+	if err != nil {
+		t.Fatalf("Files failed unexpectedly: %v", err)
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{
+			"type": "apply_progress",
+		},
+		map[string]interface{}{
+			"@message": "Apply complete!",
+			"type":     "apply_complete",
+		},
+	}
+
+	contents, _ := actual["output.json"].Json()
+	if diff := cmp.Diff(expected, contents); len(diff) > 0 {
+		t.Fatalf("unexpected diffs (-want +got):\n%s", diff)
+	}
+}
+
+func TestIgnoreFields_Invalid(t *testing.T) {
+	var specification TestSpecification
+	err := json.Unmarshal([]byte(`{"ignore_fields": {"output.json": [{"steps": []}]}}`), &specification)
+	if err == nil {
+		t.Fatalf("expected an error for an ignore rule without any steps")
+	}
+}
+
+func TestMaskFields(t *testing.T) {
+	var specification TestSpecification
+	if err := json.Unmarshal([]byte(`{
+  "ignore_fields": {
+    "output.json": ["values.removed"]
+  },
+  "mask_fields": {
+    "output.json": [
+      {"field": "values.*_at", "placeholder": "<timestamp>"},
+      {"field": {"steps": ["values", "id"]}}
+    ]
+  }
+}`), &specification); err != nil {
+		t.Fatalf("could not unmarshal specification: %v", err)
+	}
+
+	output := TestOutput{
+		Test: Test{
+			Name:          "test",
+			Specification: specification,
+		},
+		files: map[string]*files.File{
+			"output.json": files.NewJsonFile(map[string]interface{}{
+				"values": map[string]interface{}{
+					"id":         "d199d8ea",
+					"created_at": "2022-01-01T00:00:00Z",
+					"name":       "one",
+					"removed":    "two",
+				},
+			}),
+		},
+	}
+
+	actual, err := output.Files()
+	if err != nil {
+		t.Fatalf("Files failed unexpectedly: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"values": map[string]interface{}{
+			"id":         "<masked>",
+			"created_at": "<timestamp>",
+			"name":       "one",
+		},
+	}
+
+	contents, _ := actual["output.json"].Json()
+	if diff := cmp.Diff(expected, contents); len(diff) > 0 {
+		t.Fatalf("unexpected diffs (-want +got):\n%s", diff)
+	}
+}
+
+func TestMaskFields_TypeChanges(t *testing.T) {
+	var specification TestSpecification
+	if err := json.Unmarshal([]byte(`{"mask_fields": {"output.json": [{"field": "values.*"}]}}`), &specification); err != nil {
+		t.Fatalf("could not unmarshal specification: %v", err)
+	}
+
+	goldens := writeGoldens(t, "test", map[string]string{
+		"output.json": `{"values": {"count": "<masked:number>", "id": "<masked>", "tags": null}}`,
+	})
+
+	output := TestOutput{
+		Test: Test{
+			Name:          "test",
+			Specification: specification,
+		},
+		files: map[string]*files.File{
+			"output.json": files.NewJsonFile(map[string]interface{}{
+				"values": map[string]interface{}{
+					"count": map[string]interface{}{"value": json.Number("1")},
+					"id":    "d199d8ea",
+					"tags":  map[string]interface{}{},
+				},
+			}),
+		},
+	}
+
+	actual, err := output.ComputeDiff(goldens, DiffOptions{})
+	if err != nil {
+		t.Fatalf("ComputeDiff failed unexpectedly: %v", err)
+	}
+
+	// The values are masked, but the changes to their types are still
+	// reported.
+	expected := "values.count: \"<masked:number>\" -> \"<masked:object>\"\nvalues.tags: null -> \"<masked:object>\"\n"
+	if actual["output.json"] != expected {
+		t.Errorf("expected %q, but found %q", expected, actual["output.json"])
+	}
+}
+
+func TestMaskFields_Invalid(t *testing.T) {
+	var specification TestSpecification
+	err := json.Unmarshal([]byte(`{"mask_fields": {"output.json": [{"placeholder": "<id>"}]}}`), &specification)
+	if err == nil {
+		t.Fatalf("expected an error for a mask rule without a field")
+	}
+}
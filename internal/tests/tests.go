@@ -4,6 +4,7 @@
 package tests
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"os"
@@ -76,7 +77,9 @@ func ReadFrom(directory string, filters ...string) ([]Test, error) {
 // This function will return a TestOutput struct, which contains the file names
 // of the outputs that we want to compare. These files are already read in and
 // parsed in JSON objects.
-func (test Test) RunWith(tf terraform.Terraform) (TestOutput, error) {
+//
+// Any Terraform commands still running when ctx is done are killed.
+func (test Test) RunWith(ctx context.Context, tf terraform.Terraform) (TestOutput, error) {
 	tmp, err := os.MkdirTemp(test.Directory, test.Name)
 	if err != nil {
 		return TestOutput{}, err
@@ -88,13 +91,15 @@ func (test Test) RunWith(tf terraform.Terraform) (TestOutput, error) {
 		return TestOutput{}, err
 	}
 
-	files, err := tf.ExecuteTest(tmp, test.Specification.IncludeFiles, test.Specification.Commands...)
+	env := terraform.Environment{
+		Variables: test.Specification.Env,
+		Isolated:  test.Specification.IsolateEnv,
+	}
+
+	files, err := tf.ExecuteTest(ctx, tmp, env, test.Specification.IncludeFiles, test.Specification.Commands...)
 	if err != nil {
 		return TestOutput{}, err
 	}
 
-	return TestOutput{
-		Test:  test,
-		files: files,
-	}, nil
+	return newTestOutput(test, files), nil
 }
@@ -0,0 +1,168 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package text
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule is a line-oriented rule that removes or rewrites unwanted parts of a
+// raw text file, such as the human-readable output of `terraform plan`.
+//
+// Exactly one of the following must be set:
+//
+//   - Remove is a regular expression, and every line matching it is removed.
+//   - Replace is a regular expression, and every match within each line is
+//     replaced with With. With can reference capture groups, eg. `${1}`.
+//   - RemoveBetween contains the Start and End regular expressions, and every
+//     section starting at a line matching Start and ending at the next line
+//     matching End is removed, including both marker lines. If no line
+//     matches End, the section continues to the end of the file.
+type Rule struct {
+	Remove        string   `json:"remove"`
+	Replace       string   `json:"replace"`
+	With          string   `json:"with"`
+	RemoveBetween *Section `json:"remove_between"`
+
+	remove  *regexp.Regexp
+	replace *regexp.Regexp
+	start   *regexp.Regexp
+	end     *regexp.Regexp
+}
+
+// Section is the pair of markers used by Rule.RemoveBetween.
+type Section struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+func (rule *Rule) UnmarshalJSON(data []byte) error {
+	type textRule Rule
+	if err := json.Unmarshal(data, (*textRule)(rule)); err != nil {
+		return err
+	}
+	return rule.compile()
+}
+
+func (rule *Rule) compile() error {
+	set := 0
+	var err error
+	if len(rule.Remove) > 0 {
+		set++
+		if rule.remove, err = compile(rule.Remove); err != nil {
+			return err
+		}
+	}
+	if len(rule.Replace) > 0 {
+		set++
+		if rule.replace, err = compile(rule.Replace); err != nil {
+			return err
+		}
+	}
+	if rule.RemoveBetween != nil {
+		set++
+		if len(rule.RemoveBetween.Start) == 0 || len(rule.RemoveBetween.End) == 0 {
+			return errors.New("remove_between requires both a start and an end")
+		}
+		if rule.start, err = compile(rule.RemoveBetween.Start); err != nil {
+			return err
+		}
+		if rule.end, err = compile(rule.RemoveBetween.End); err != nil {
+			return err
+		}
+	}
+
+	if set != 1 {
+		return errors.New("text rules must set exactly one of remove, replace, or remove_between")
+	}
+	if len(rule.With) > 0 && rule.replace == nil {
+		return errors.New("with can only be set alongside replace")
+	}
+	return nil
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %s: %v", pattern, err)
+	}
+	return regex, nil
+}
+
+// String returns a short description of the rule, for use in warnings and
+// errors.
+func (rule Rule) String() string {
+	switch {
+	case len(rule.Remove) > 0:
+		return fmt.Sprintf("remove %s", rule.Remove)
+	case len(rule.Replace) > 0:
+		return fmt.Sprintf("replace %s with %s", rule.Replace, rule.With)
+	case rule.RemoveBetween != nil:
+		return fmt.Sprintf("remove between %s and %s", rule.RemoveBetween.Start, rule.RemoveBetween.End)
+	default:
+		return "empty rule"
+	}
+}
+
+// Apply applies each of the rules to contents in order, and returns the result
+// along with the number of lines each rule removed or changed.
+func Apply(rules []Rule, contents string) (string, []int, error) {
+	counts := make([]int, len(rules))
+	for ix, rule := range rules {
+		if rule.remove == nil && rule.replace == nil && rule.start == nil {
+			// The rule was built directly rather than unmarshalled, so it
+			// hasn't been compiled yet.
+			if err := rule.compile(); err != nil {
+				return "", nil, err
+			}
+		}
+		contents, counts[ix] = rule.apply(contents)
+	}
+	return contents, counts, nil
+}
+
+func (rule Rule) apply(contents string) (string, int) {
+	var ret strings.Builder
+	count := 0
+	inSection := false
+	for _, line := range strings.SplitAfter(contents, "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		text := strings.TrimSuffix(line, "\n")
+		newline := line[len(text):]
+
+		switch {
+		case rule.remove != nil:
+			if rule.remove.MatchString(text) {
+				count++
+				continue
+			}
+		case rule.replace != nil:
+			if rule.replace.MatchString(text) {
+				count++
+				line = rule.replace.ReplaceAllString(text, rule.With) + newline
+			}
+		case rule.start != nil:
+			if !inSection && rule.start.MatchString(text) {
+				inSection = true
+				count++
+				continue
+			}
+			if inSection {
+				if rule.end.MatchString(text) {
+					inSection = false
+				}
+				count++
+				continue
+			}
+		}
+		ret.WriteString(line)
+	}
+	return ret.String(), count
+}
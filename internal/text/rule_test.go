@@ -0,0 +1,156 @@
+// Copyright IBM Corp. 2022, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package text
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApply(t *testing.T) {
+	input := `Terraform v1.5.0
+on linux_amd64
+
+Terraform will perform the following actions:
+
+  # random_pet.one will be created
+  + resource "random_pet" "one" {
+      + id = (known after apply)
+    }
+
+Plan: 1 to add, 0 to change, 0 to destroy.
+random_pet.one: Creation complete after 0s [id=happy-dog]
+
+─────────────────────────────────────────────────────────────────────────────
+
+Note: You didn't use the -out option to save this plan, so Terraform can't
+guarantee to take exactly these actions if you run "terraform apply" now.
+`
+
+	tcs := map[string]struct {
+		rules    string
+		expected string
+		counts   []int
+	}{
+		"remove": {
+			rules: `[{"remove": "^Terraform v"}, {"remove": "^on "}]`,
+			expected: `
+Terraform will perform the following actions:
+
+  # random_pet.one will be created
+  + resource "random_pet" "one" {
+      + id = (known after apply)
+    }
+
+Plan: 1 to add, 0 to change, 0 to destroy.
+random_pet.one: Creation complete after 0s [id=happy-dog]
+
+─────────────────────────────────────────────────────────────────────────────
+
+Note: You didn't use the -out option to save this plan, so Terraform can't
+guarantee to take exactly these actions if you run "terraform apply" now.
+`,
+			counts: []int{1, 1},
+		},
+		"replace": {
+			rules: `[{"replace": "complete after [0-9.]+m?s", "with": "complete after <elapsed>"}, {"replace": "v([0-9]+)\\.[0-9.]+", "with": "v${1}.x"}]`,
+			expected: `Terraform v1.x
+on linux_amd64
+
+Terraform will perform the following actions:
+
+  # random_pet.one will be created
+  + resource "random_pet" "one" {
+      + id = (known after apply)
+    }
+
+Plan: 1 to add, 0 to change, 0 to destroy.
+random_pet.one: Creation complete after <elapsed> [id=happy-dog]
+
+─────────────────────────────────────────────────────────────────────────────
+
+Note: You didn't use the -out option to save this plan, so Terraform can't
+guarantee to take exactly these actions if you run "terraform apply" now.
+`,
+			counts: []int{1, 1},
+		},
+		"remove_between": {
+			rules: `[{"remove_between": {"start": "^─+$", "end": "terraform apply\" now\\.$"}}, {"remove_between": {"start": "^Terraform v", "end": "^$"}}]`,
+			expected: `Terraform will perform the following actions:
+
+  # random_pet.one will be created
+  + resource "random_pet" "one" {
+      + id = (known after apply)
+    }
+
+Plan: 1 to add, 0 to change, 0 to destroy.
+random_pet.one: Creation complete after 0s [id=happy-dog]
+
+`,
+			counts: []int{4, 3},
+		},
+		"remove_between_no_end": {
+			rules: `[{"remove_between": {"start": "^Plan:", "end": "^never$"}}]`,
+			expected: `Terraform v1.5.0
+on linux_amd64
+
+Terraform will perform the following actions:
+
+  # random_pet.one will be created
+  + resource "random_pet" "one" {
+      + id = (known after apply)
+    }
+
+`,
+			counts: []int{7},
+		},
+		"no_match": {
+			rules:    `[{"remove": "^Warning:"}]`,
+			expected: input,
+			counts:   []int{0},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var rules []Rule
+			if err := json.Unmarshal([]byte(tc.rules), &rules); err != nil {
+				t.Fatalf("could not unmarshal rules: %v", err)
+			}
+
+			actual, counts, err := Apply(rules, input)
+			if err != nil {
+				t.Fatalf("Apply failed unexpectedly: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, actual); len(diff) > 0 {
+				t.Errorf("unexpected output (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.counts, counts); len(diff) > 0 {
+				t.Errorf("unexpected counts (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRule_Invalid(t *testing.T) {
+	tcs := map[string]string{
+		"empty":          `{}`,
+		"multiple":       `{"remove": "a", "replace": "b"}`,
+		"invalid_regex":  `{"remove": "[a"}`,
+		"with_no_regex":  `{"remove": "a", "with": "b"}`,
+		"missing_marker": `{"remove_between": {"start": "a"}}`,
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			var rule Rule
+			if err := json.Unmarshal([]byte(tc), &rule); err == nil {
+				t.Errorf("expected an error for %s", tc)
+			}
+		})
+	}
+}
@@ -27,8 +27,9 @@ func main() {
 
 	command.Args = os.Args[1:]
 	command.Commands = map[string]cli.CommandFactory{
-		"diff":   cmd.DiffCommandFactory(&ui),
-		"update": cmd.UpdateCommandFactory(&ui),
+		"compare": cmd.CompareCommandFactory(&ui),
+		"diff":    cmd.DiffCommandFactory(&ui),
+		"update":  cmd.UpdateCommandFactory(&ui),
 	}
 	command.HelpFunc = cli.BasicHelpFunc("terraform-equivalence-testing")
 	command.HelpWriter = os.Stdout